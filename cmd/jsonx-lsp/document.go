@@ -0,0 +1,103 @@
+package main
+
+import "github.com/sourcegraph/jsonx/internal/lineindex"
+
+// A document is an open text document. It converts between the character
+// offsets used by jsonx and the LSP positions, whose characters are UTF-16 code
+// units.
+type document struct {
+	uri        string
+	languageID string
+	version    int
+	text       string
+
+	chars []rune
+	lines lineindex.Index
+}
+
+func newDocument(uri, languageID string, version int, text string) *document {
+	d := &document{uri: uri, languageID: languageID, version: version}
+	d.setText(text)
+	return d
+}
+
+// setText sets the content of the document.
+func (d *document) setText(text string) {
+	d.text = text
+	d.chars = []rune(text)
+	d.lines = lineindex.New(d.chars)
+}
+
+// applyChange applies the change event to the content of the document.
+func (d *document) applyChange(change TextDocumentContentChangeEvent) {
+	if change.Range == nil {
+		d.setText(change.Text)
+		return
+	}
+	start, end := d.offsetAt(change.Range.Start), d.offsetAt(change.Range.End)
+	if end < start {
+		start, end = end, start
+	}
+	d.setText(string(d.chars[:start]) + change.Text + string(d.chars[end:]))
+}
+
+// lineEnd returns the character offset of the end of the line (before its line
+// break).
+func (d *document) lineEnd(line int) int {
+	if line+1 >= len(d.lines) {
+		return len(d.chars)
+	}
+	end := d.lines[line+1] - 1
+	if end > d.lines[line] && d.chars[end] == '\n' && d.chars[end-1] == '\r' {
+		end--
+	}
+	return end
+}
+
+// offsetAt returns the character offset of the position, clamped to the document
+// and to the end of the position's line.
+func (d *document) offsetAt(pos Position) int {
+	if pos.Line < 0 {
+		return 0
+	}
+	if pos.Line >= len(d.lines) {
+		return len(d.chars)
+	}
+	offset, end := d.lines[pos.Line], d.lineEnd(pos.Line)
+	for units := 0; offset < end; offset++ {
+		units += utf16Len(d.chars[offset])
+		if units > pos.Character {
+			break
+		}
+	}
+	return offset
+}
+
+// positionAt returns the position of the character offset, clamped to the
+// document.
+func (d *document) positionAt(offset int) Position {
+	if offset < 0 {
+		offset = 0
+	} else if offset > len(d.chars) {
+		offset = len(d.chars)
+	}
+	line := d.lines.Line(offset)
+	character := 0
+	for _, ch := range d.chars[d.lines[line]:offset] {
+		character += utf16Len(ch)
+	}
+	return Position{Line: line, Character: character}
+}
+
+// rangeOf returns the range of the characters at the offset with the length.
+func (d *document) rangeOf(offset, length int) Range {
+	return Range{Start: d.positionAt(offset), End: d.positionAt(offset + length)}
+}
+
+// utf16Len returns the number of UTF-16 code units of the character.
+func utf16Len(ch rune) int {
+	if ch >= 0x10000 {
+		return 2 // surrogate pair
+	}
+	return 1
+}
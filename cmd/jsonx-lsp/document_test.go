@@ -0,0 +1,58 @@
+package main
+
+import "testing"
+
+func TestDocument_positions(t *testing.T) {
+	doc := newDocument("file:///a.json", "jsonc", 1, "a\r\n😀b\rc\n")
+	tests := []struct {
+		offset int
+		pos    Position
+	}{
+		{0, Position{0, 0}},
+		{1, Position{0, 1}},
+		{3, Position{1, 0}},
+		{4, Position{1, 2}},
+		{5, Position{1, 3}},
+		{6, Position{2, 0}},
+		{8, Position{3, 0}},
+	}
+	for _, test := range tests {
+		if got := doc.positionAt(test.offset); got != test.pos {
+			t.Errorf("positionAt(%d) = %+v, want %+v", test.offset, got, test.pos)
+		}
+		if got := doc.offsetAt(test.pos); got != test.offset {
+			t.Errorf("offsetAt(%+v) = %d, want %d", test.pos, got, test.offset)
+		}
+	}
+
+	// Positions past the end of a line or the document are clamped.
+	clamped := []struct {
+		pos    Position
+		offset int
+	}{
+		{Position{0, 5}, 1},
+		{Position{1, 1}, 3}, // inside the surrogate pair
+		{Position{9, 0}, 8},
+		{Position{-1, 0}, 0},
+	}
+	for _, test := range clamped {
+		if got := doc.offsetAt(test.pos); got != test.offset {
+			t.Errorf("offsetAt(%+v) = %d, want %d", test.pos, got, test.offset)
+		}
+	}
+}
+
+func TestDocument_applyChange(t *testing.T) {
+	doc := newDocument("file:///a.json", "jsonc", 1, "[1,\n2]")
+	doc.applyChange(TextDocumentContentChangeEvent{Range: &Range{Start: Position{0, 1}, End: Position{1, 1}}, Text: "3"})
+	if want := "[3]"; doc.text != want {
+		t.Errorf("got %q, want %q", doc.text, want)
+	}
+	if got := doc.positionAt(3); got != (Position{0, 3}) {
+		t.Errorf("got %+v after the change, want line 0", got)
+	}
+	doc.applyChange(TextDocumentContentChangeEvent{Text: "{}"})
+	if want := "{}"; doc.text != want {
+		t.Errorf("got %q, want %q", doc.text, want)
+	}
+}
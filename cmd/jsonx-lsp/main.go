@@ -0,0 +1,13 @@
+// Command jsonx-lsp is a Language Server Protocol server for JSON documents with
+// comments and trailing commas (JSONC). It communicates with the client over
+// standard input and output.
+//
+// The server reports syntax errors as diagnostics, formats documents and ranges,
+// and provides folding ranges, document symbols and selection ranges.
+package main
+
+import "os"
+
+func main() {
+	os.Exit(newServer(os.Stdin, os.Stdout, os.Stderr).serve())
+}
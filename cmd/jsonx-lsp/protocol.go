@@ -0,0 +1,186 @@
+package main
+
+// The subset of the Language Server Protocol types used by the server. See
+// https://microsoft.github.io/language-server-protocol/specifications/specification-current/.
+
+// A Position is a zero-based line and character offset (in UTF-16 code units)
+// in a text document.
+type Position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+// A Range is a range in a text document, exclusive of the end position.
+type Range struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+type TextDocumentIdentifier struct {
+	URI string `json:"uri"`
+}
+
+type TextDocumentItem struct {
+	URI        string `json:"uri"`
+	LanguageID string `json:"languageId"`
+	Version    int    `json:"version"`
+	Text       string `json:"text"`
+}
+
+type VersionedTextDocumentIdentifier struct {
+	URI     string `json:"uri"`
+	Version int    `json:"version"`
+}
+
+type TextEdit struct {
+	Range   Range  `json:"range"`
+	NewText string `json:"newText"`
+}
+
+type InitializeParams struct {
+	Capabilities ClientCapabilities `json:"capabilities"`
+}
+
+type ClientCapabilities struct {
+	TextDocument struct {
+		FoldingRange struct {
+			RangeLimit int `json:"rangeLimit,omitempty"`
+		} `json:"foldingRange"`
+	} `json:"textDocument"`
+}
+
+type InitializeResult struct {
+	Capabilities ServerCapabilities `json:"capabilities"`
+	ServerInfo   *ServerInfo        `json:"serverInfo,omitempty"`
+}
+
+type ServerCapabilities struct {
+	TextDocumentSync                TextDocumentSyncKind `json:"textDocumentSync"`
+	DocumentFormattingProvider      bool                 `json:"documentFormattingProvider,omitempty"`
+	DocumentRangeFormattingProvider bool                 `json:"documentRangeFormattingProvider,omitempty"`
+	FoldingRangeProvider            bool                 `json:"foldingRangeProvider,omitempty"`
+	DocumentSymbolProvider          bool                 `json:"documentSymbolProvider,omitempty"`
+	SelectionRangeProvider          bool                 `json:"selectionRangeProvider,omitempty"`
+}
+
+type ServerInfo struct {
+	Name string `json:"name"`
+}
+
+// TextDocumentSyncKind is how the client sends document changes to the server.
+type TextDocumentSyncKind int
+
+// Text document sync kinds
+const (
+	SyncNone TextDocumentSyncKind = iota
+	SyncFull
+	SyncIncremental
+)
+
+type DidOpenTextDocumentParams struct {
+	TextDocument TextDocumentItem `json:"textDocument"`
+}
+
+type DidChangeTextDocumentParams struct {
+	TextDocument   VersionedTextDocumentIdentifier  `json:"textDocument"`
+	ContentChanges []TextDocumentContentChangeEvent `json:"contentChanges"`
+}
+
+// A TextDocumentContentChangeEvent is a change of a text document. If Range is nil,
+// Text is the full content of the document.
+type TextDocumentContentChangeEvent struct {
+	Range *Range `json:"range,omitempty"`
+	Text  string `json:"text"`
+}
+
+type DidCloseTextDocumentParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+}
+
+type PublishDiagnosticsParams struct {
+	URI         string       `json:"uri"`
+	Version     *int         `json:"version,omitempty"`
+	Diagnostics []Diagnostic `json:"diagnostics"`
+}
+
+type Diagnostic struct {
+	Range    Range              `json:"range"`
+	Severity DiagnosticSeverity `json:"severity,omitempty"`
+	Source   string             `json:"source,omitempty"`
+	Message  string             `json:"message"`
+}
+
+// DiagnosticSeverity is the severity of a diagnostic.
+type DiagnosticSeverity int
+
+// Diagnostic severities
+const (
+	SeverityError DiagnosticSeverity = iota + 1
+	SeverityWarning
+	SeverityInformation
+	SeverityHint
+)
+
+type FormattingOptions struct {
+	TabSize            int    `json:"tabSize"`
+	InsertSpaces       bool   `json:"insertSpaces"`
+	InsertFinalNewline bool   `json:"insertFinalNewline,omitempty"`
+	EOL                string `json:"eol,omitempty"` // an additional property (not in the LSP spec), for documents without line breaks
+}
+
+type DocumentFormattingParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Options      FormattingOptions      `json:"options"`
+}
+
+type DocumentRangeFormattingParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Range        Range                  `json:"range"`
+	Options      FormattingOptions      `json:"options"`
+}
+
+type FoldingRangeParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+}
+
+type FoldingRange struct {
+	StartLine int    `json:"startLine"`
+	EndLine   int    `json:"endLine"`
+	Kind      string `json:"kind,omitempty"` // "comment", "imports" or "region"
+}
+
+type DocumentSymbolParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+}
+
+type DocumentSymbol struct {
+	Name           string           `json:"name"`
+	Detail         string           `json:"detail,omitempty"`
+	Kind           SymbolKind       `json:"kind"`
+	Range          Range            `json:"range"`
+	SelectionRange Range            `json:"selectionRange"`
+	Children       []DocumentSymbol `json:"children,omitempty"`
+}
+
+// SymbolKind is the kind of a symbol.
+type SymbolKind int
+
+// Symbol kinds (of those defined by the protocol, the ones for JSON values)
+const (
+	SymbolString  SymbolKind = 15
+	SymbolNumber  SymbolKind = 16
+	SymbolBoolean SymbolKind = 17
+	SymbolArray   SymbolKind = 18
+	SymbolObject  SymbolKind = 19
+	SymbolNull    SymbolKind = 21
+)
+
+type SelectionRangeParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Positions    []Position             `json:"positions"`
+}
+
+type SelectionRange struct {
+	Range  Range           `json:"range"`
+	Parent *SelectionRange `json:"parent,omitempty"`
+}
@@ -0,0 +1,317 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/sourcegraph/jsonx"
+)
+
+var parseOptions = jsonx.ParseOptions{Comments: true, TrailingCommas: true}
+
+// symbolLimit is the maximum number of document symbols per document.
+const symbolLimit = 5000
+
+// A server is a language server for JSONC documents.
+type server struct {
+	conn *conn
+	log  io.Writer
+
+	initialized bool
+	shutdown    bool
+	docs        map[string]*document // by URI
+	rangeLimit  int                  // the maximum number of folding ranges per document (0 for no limit)
+}
+
+func newServer(r io.Reader, w, log io.Writer) *server {
+	return &server{conn: newConn(r, w), log: log, docs: map[string]*document{}}
+}
+
+// serve handles messages until the client sends the exit notification or closes
+// the connection. It returns the exit code of the server.
+func (s *server) serve() int {
+	for {
+		msg, err := s.conn.read()
+		if err == io.EOF {
+			return 1
+		}
+		if err != nil {
+			if rerr, ok := err.(*rpcError); ok && rerr.Code == codeParseError {
+				s.logf("%s", err)
+				if err := s.conn.reply(json.RawMessage("null"), nil, err); err != nil {
+					return 1
+				}
+				continue
+			}
+			s.logf("%s", err)
+			return 1
+		}
+		if msg.Method == "exit" {
+			if s.shutdown {
+				return 0
+			}
+			return 1
+		}
+		if msg.Method == "" {
+			continue // a response to a request of the server (there are none)
+		}
+
+		result, err := s.handle(msg)
+		if msg.ID == nil {
+			if err != nil {
+				s.logf("%s: %s", msg.Method, err)
+			}
+			continue
+		}
+		if err := s.conn.reply(msg.ID, result, err); err != nil {
+			s.logf("%s", err)
+			return 1
+		}
+	}
+}
+
+// handle handles the request or notification and returns its result.
+func (s *server) handle(msg *message) (interface{}, error) {
+	if !s.initialized && msg.Method != "initialize" {
+		return nil, &rpcError{Code: codeServerNotInitialized, Message: "server not initialized"}
+	}
+	if s.shutdown {
+		return nil, &rpcError{Code: codeInvalidRequest, Message: "server is shut down"}
+	}
+
+	switch msg.Method {
+	case "initialize":
+		var params InitializeParams
+		if err := unmarshalParams(msg.Params, &params); err != nil {
+			return nil, err
+		}
+		s.initialized = true
+		s.rangeLimit = params.Capabilities.TextDocument.FoldingRange.RangeLimit
+		return InitializeResult{
+			Capabilities: ServerCapabilities{
+				TextDocumentSync:                SyncIncremental,
+				DocumentFormattingProvider:      true,
+				DocumentRangeFormattingProvider: true,
+				FoldingRangeProvider:            true,
+				DocumentSymbolProvider:          true,
+				SelectionRangeProvider:          true,
+			},
+			ServerInfo: &ServerInfo{Name: "jsonx-lsp"},
+		}, nil
+	case "initialized":
+		return nil, nil
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+
+	case "textDocument/didOpen":
+		var params DidOpenTextDocumentParams
+		if err := unmarshalParams(msg.Params, &params); err != nil {
+			return nil, err
+		}
+		item := params.TextDocument
+		doc := newDocument(item.URI, item.LanguageID, item.Version, item.Text)
+		s.docs[item.URI] = doc
+		return nil, s.publishDiagnostics(doc)
+	case "textDocument/didChange":
+		var params DidChangeTextDocumentParams
+		if err := unmarshalParams(msg.Params, &params); err != nil {
+			return nil, err
+		}
+		doc, err := s.document(params.TextDocument.URI)
+		if err != nil {
+			return nil, err
+		}
+		for _, change := range params.ContentChanges {
+			doc.applyChange(change)
+		}
+		doc.version = params.TextDocument.Version
+		return nil, s.publishDiagnostics(doc)
+	case "textDocument/didClose":
+		var params DidCloseTextDocumentParams
+		if err := unmarshalParams(msg.Params, &params); err != nil {
+			return nil, err
+		}
+		delete(s.docs, params.TextDocument.URI)
+		return nil, s.conn.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{URI: params.TextDocument.URI, Diagnostics: []Diagnostic{}})
+
+	case "textDocument/formatting":
+		var params DocumentFormattingParams
+		if err := unmarshalParams(msg.Params, &params); err != nil {
+			return nil, err
+		}
+		doc, err := s.document(params.TextDocument.URI)
+		if err != nil {
+			return nil, err
+		}
+		return doc.textEdits(jsonx.Format(doc.text, formatOptions(params.Options))), nil
+	case "textDocument/rangeFormatting":
+		var params DocumentRangeFormattingParams
+		if err := unmarshalParams(msg.Params, &params); err != nil {
+			return nil, err
+		}
+		doc, err := s.document(params.TextDocument.URI)
+		if err != nil {
+			return nil, err
+		}
+		start, end := doc.offsetAt(params.Range.Start), doc.offsetAt(params.Range.End)
+		return doc.textEdits(jsonx.FormatRange(doc.text, start, end-start, formatOptions(params.Options))), nil
+
+	case "textDocument/foldingRange":
+		var params FoldingRangeParams
+		if err := unmarshalParams(msg.Params, &params); err != nil {
+			return nil, err
+		}
+		doc, err := s.document(params.TextDocument.URI)
+		if err != nil {
+			return nil, err
+		}
+		return foldingRanges(jsonx.GetFoldingRanges(doc.text, jsonx.FoldingRangeOptions{RangeLimit: s.rangeLimit})), nil
+
+	case "textDocument/documentSymbol":
+		var params DocumentSymbolParams
+		if err := unmarshalParams(msg.Params, &params); err != nil {
+			return nil, err
+		}
+		doc, err := s.document(params.TextDocument.URI)
+		if err != nil {
+			return nil, err
+		}
+		root, _ := jsonx.ParseTree(doc.text, parseOptions)
+		return doc.symbols(jsonx.GetDocumentSymbols(root, doc.text, jsonx.DocumentSymbolOptions{ResultLimit: symbolLimit})), nil
+
+	case "textDocument/selectionRange":
+		var params SelectionRangeParams
+		if err := unmarshalParams(msg.Params, &params); err != nil {
+			return nil, err
+		}
+		doc, err := s.document(params.TextDocument.URI)
+		if err != nil {
+			return nil, err
+		}
+		offsets := make([]int, len(params.Positions))
+		for i, pos := range params.Positions {
+			offsets[i] = doc.offsetAt(pos)
+		}
+		ranges := jsonx.GetSelectionRanges(doc.text, offsets)
+		result := make([]*SelectionRange, len(ranges))
+		for i := range ranges {
+			result[i] = doc.selectionRange(&ranges[i])
+		}
+		return result, nil
+	}
+
+	if msg.ID == nil {
+		return nil, nil // ignore unknown notifications
+	}
+	return nil, &rpcError{Code: codeMethodNotFound, Message: fmt.Sprintf("method not found: %s", msg.Method)}
+}
+
+// document returns the open document with the URI.
+func (s *server) document(uri string) (*document, error) {
+	doc, ok := s.docs[uri]
+	if !ok {
+		return nil, &rpcError{Code: codeInvalidParams, Message: fmt.Sprintf("document not open: %s", uri)}
+	}
+	return doc, nil
+}
+
+// publishDiagnostics sends the syntax errors of the document to the client.
+func (s *server) publishDiagnostics(doc *document) error {
+	_, errors := jsonx.ParseWithDetailedErrors(doc.text, parseOptions)
+	diagnostics := make([]Diagnostic, len(errors))
+	for i, err := range errors {
+		diagnostics[i] = Diagnostic{
+			Range:    doc.rangeOf(err.Offset, err.Length),
+			Severity: SeverityError,
+			Source:   "jsonx",
+			Message:  err.Code.String(),
+		}
+	}
+	version := doc.version
+	return s.conn.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{URI: doc.uri, Version: &version, Diagnostics: diagnostics})
+}
+
+func (s *server) logf(format string, args ...interface{}) {
+	if s.log != nil {
+		fmt.Fprintf(s.log, "jsonx-lsp: "+format+"\n", args...)
+	}
+}
+
+// formatOptions returns the jsonx format options for the LSP formatting options.
+func formatOptions(options FormattingOptions) jsonx.FormatOptions {
+	return jsonx.FormatOptions{
+		TabSize:            options.TabSize,
+		InsertSpaces:       options.InsertSpaces,
+		InsertFinalNewline: options.InsertFinalNewline,
+		EOL:                options.EOL,
+	}
+}
+
+// textEdits returns the LSP text edits for the jsonx edits of the document.
+func (d *document) textEdits(edits []jsonx.Edit) []TextEdit {
+	textEdits := make([]TextEdit, len(edits))
+	for i, edit := range edits {
+		textEdits[i] = TextEdit{Range: d.rangeOf(edit.Offset, edit.Length), NewText: edit.Content}
+	}
+	return textEdits
+}
+
+// foldingRanges returns the LSP folding ranges for the jsonx folding ranges.
+func foldingRanges(ranges []jsonx.FoldingRange) []FoldingRange {
+	kinds := map[jsonx.FoldingRangeKind]string{
+		jsonx.CommentFoldingRange: "comment",
+		jsonx.RegionFoldingRange:  "region",
+	}
+	result := make([]FoldingRange, len(ranges))
+	for i, r := range ranges {
+		result[i] = FoldingRange{StartLine: r.StartLine, EndLine: r.EndLine, Kind: kinds[r.Kind]}
+	}
+	return result
+}
+
+var symbolKinds = map[jsonx.NodeType]SymbolKind{
+	jsonx.Object:  SymbolObject,
+	jsonx.Array:   SymbolArray,
+	jsonx.String:  SymbolString,
+	jsonx.Number:  SymbolNumber,
+	jsonx.Boolean: SymbolBoolean,
+	jsonx.Null:    SymbolNull,
+}
+
+// symbols returns the LSP document symbols for the jsonx document symbols of the
+// document.
+func (d *document) symbols(symbols []jsonx.DocumentSymbol) []DocumentSymbol {
+	result := make([]DocumentSymbol, len(symbols))
+	for i, symbol := range symbols {
+		result[i] = DocumentSymbol{
+			Name:           symbol.Name,
+			Detail:         symbol.Detail,
+			Kind:           symbolKinds[symbol.Kind],
+			Range:          d.rangeOf(symbol.Range.Offset, symbol.Range.Length),
+			SelectionRange: d.rangeOf(symbol.SelectionRange.Offset, symbol.SelectionRange.Length),
+			Children:       d.symbols(symbol.Children),
+		}
+	}
+	return result
+}
+
+// selectionRange returns the LSP selection range for the jsonx selection range of
+// the document.
+func (d *document) selectionRange(r *jsonx.SelectionRange) *SelectionRange {
+	if r == nil {
+		return nil
+	}
+	return &SelectionRange{Range: d.rangeOf(r.Range.Offset, r.Range.Length), Parent: d.selectionRange(r.Parent)}
+}
+
+func unmarshalParams(params json.RawMessage, v interface{}) error {
+	if len(params) == 0 {
+		return nil // omitted
+	}
+	if err := json.Unmarshal(params, v); err != nil {
+		return &rpcError{Code: codeInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// session runs the server with the messages sent by the client (followed by the
+// shutdown request and exit notification) and returns the messages sent by the
+// server, excluding the response to the shutdown request.
+func session(t *testing.T, messages ...string) []*message {
+	t.Helper()
+	messages = append([]string{`{"jsonrpc": "2.0", "id": 0, "method": "initialize", "params": {}}`}, messages...)
+	messages = append(messages, `{"jsonrpc": "2.0", "id": 999, "method": "shutdown"}`, `{"jsonrpc": "2.0", "method": "exit"}`)
+	var in, out, log bytes.Buffer
+	for _, msg := range messages {
+		fmt.Fprintf(&in, "Content-Length: %d\r\n\r\n%s", len(msg), msg)
+	}
+	if code := newServer(&in, &out, &log).serve(); code != 0 {
+		t.Fatalf("got exit code %d (log: %s)", code, log.String())
+	}
+	if log.Len() > 0 {
+		t.Errorf("log: %s", log.String())
+	}
+
+	var got []*message
+	c := newConn(&out, nil)
+	for {
+		msg, err := c.read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, msg)
+	}
+	if len(got) < 2 || string(got[len(got)-1].ID) != "999" {
+		t.Fatalf("got %d messages, want the initialize and shutdown responses", len(got))
+	}
+	return got[1 : len(got)-1]
+}
+
+func didOpen(text string) string {
+	data, _ := json.Marshal(text)
+	return `{"jsonrpc": "2.0", "method": "textDocument/didOpen", "params": {"textDocument": {"uri": "file:///a.json", "languageId": "jsonc", "version": 1, "text": ` + string(data) + `}}}`
+}
+
+func checkMessage(t *testing.T, msg *message, wantMethod, wantParamsOrResult string) {
+	t.Helper()
+	data := msg.Result
+	if wantMethod != "" {
+		if msg.Method != wantMethod {
+			t.Errorf("got method %q, want %q", msg.Method, wantMethod)
+		}
+		data = msg.Params
+	}
+	var got, want interface{}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("%s: %s", data, err)
+	}
+	if err := json.Unmarshal([]byte(wantParamsOrResult), &want); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got  %s\nwant %s", data, wantParamsOrResult)
+	}
+}
+
+func TestServer_initialize(t *testing.T) {
+	var in, out bytes.Buffer
+	msg := `{"jsonrpc": "2.0", "id": 1, "method": "initialize", "params": {}}`
+	fmt.Fprintf(&in, "Content-Length: %d\r\n\r\n%s", len(msg), msg)
+	if code := newServer(&in, &out, nil).serve(); code != 1 {
+		t.Errorf("got exit code %d without shutdown, want 1", code)
+	}
+	got, err := newConn(&out, nil).read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkMessage(t, got, "", `{
+		"capabilities": {
+			"textDocumentSync": 2,
+			"documentFormattingProvider": true,
+			"documentRangeFormattingProvider": true,
+			"foldingRangeProvider": true,
+			"documentSymbolProvider": true,
+			"selectionRangeProvider": true
+		},
+		"serverInfo": {"name": "jsonx-lsp"}
+	}`)
+}
+
+func TestServer_diagnostics(t *testing.T) {
+	got := session(t,
+		didOpen("{\n  \"a\": 1 \"b\"\n}"),
+		`{"jsonrpc": "2.0", "method": "textDocument/didChange", "params": {"textDocument": {"uri": "file:///a.json", "version": 2}, "contentChanges": [{"range": {"start": {"line": 1, "character": 8}, "end": {"line": 1, "character": 8}}, "text": ","}]}}`,
+		`{"jsonrpc": "2.0", "method": "textDocument/didClose", "params": {"textDocument": {"uri": "file:///a.json"}}}`,
+	)
+	if len(got) != 3 {
+		t.Fatalf("got %d messages, want 3", len(got))
+	}
+	checkMessage(t, got[0], "textDocument/publishDiagnostics", `{
+		"uri": "file:///a.json",
+		"version": 1,
+		"diagnostics": [
+			{"range": {"start": {"line": 1, "character": 9}, "end": {"line": 1, "character": 12}}, "severity": 1, "source": "jsonx", "message": "CommaExpected"},
+			{"range": {"start": {"line": 2, "character": 0}, "end": {"line": 2, "character": 1}}, "severity": 1, "source": "jsonx", "message": "ColonExpected"}
+		]
+	}`)
+	checkMessage(t, got[1], "textDocument/publishDiagnostics", `{"uri": "file:///a.json", "version": 2, "diagnostics": [
+		{"range": {"start": {"line": 2, "character": 0}, "end": {"line": 2, "character": 1}}, "severity": 1, "source": "jsonx", "message": "ColonExpected"}
+	]}`)
+	checkMessage(t, got[2], "textDocument/publishDiagnostics", `{"uri": "file:///a.json", "diagnostics": []}`)
+}
+
+func TestServer_formatting(t *testing.T) {
+	got := session(t,
+		didOpen("{\"a\": [1,\n2], \"😀\": {}}"),
+		`{"jsonrpc": "2.0", "id": 1, "method": "textDocument/formatting", "params": {"textDocument": {"uri": "file:///a.json"}, "options": {"tabSize": 2, "insertSpaces": true}}}`,
+		`{"jsonrpc": "2.0", "id": 2, "method": "textDocument/rangeFormatting", "params": {"textDocument": {"uri": "file:///a.json"}, "range": {"start": {"line": 1, "character": 0}, "end": {"line": 1, "character": 2}}, "options": {"tabSize": 2, "insertSpaces": true}}}`,
+		`{"jsonrpc": "2.0", "id": 3, "method": "textDocument/formatting", "params": {"textDocument": {"uri": "file:///b.json"}, "options": {"tabSize": 2, "insertSpaces": true}}}`,
+		`{"jsonrpc": "2.0", "id": 4, "method": "textDocument/unknown", "params": {}}`,
+	)
+	if len(got) != 5 {
+		t.Fatalf("got %d messages, want 5", len(got))
+	}
+	checkMessage(t, got[1], "", `[
+		{"range": {"start": {"line": 0, "character": 1}, "end": {"line": 0, "character": 1}}, "newText": "\n  "},
+		{"range": {"start": {"line": 0, "character": 7}, "end": {"line": 0, "character": 7}}, "newText": "\n    "},
+		{"range": {"start": {"line": 0, "character": 9}, "end": {"line": 1, "character": 0}}, "newText": "\n    "},
+		{"range": {"start": {"line": 1, "character": 1}, "end": {"line": 1, "character": 1}}, "newText": "\n  "},
+		{"range": {"start": {"line": 1, "character": 3}, "end": {"line": 1, "character": 4}}, "newText": "\n  "},
+		{"range": {"start": {"line": 1, "character": 12}, "end": {"line": 1, "character": 12}}, "newText": "\n"}
+	]`)
+	checkMessage(t, got[2], "", `[
+		{"range": {"start": {"line": 1, "character": 1}, "end": {"line": 1, "character": 1}}, "newText": "\n"}
+	]`)
+	if got[3].Error == nil || got[3].Error.Code != codeInvalidParams {
+		t.Errorf("got error %v for a document that is not open, want code %d", got[3].Error, codeInvalidParams)
+	}
+	if got[4].Error == nil || got[4].Error.Code != codeMethodNotFound {
+		t.Errorf("got error %v for an unknown method, want code %d", got[4].Error, codeMethodNotFound)
+	}
+}
+
+func TestServer_formattingEOL(t *testing.T) {
+	got := session(t,
+		didOpen(`{"a": 1}`),
+		`{"jsonrpc": "2.0", "id": 1, "method": "textDocument/formatting", "params": {"textDocument": {"uri": "file:///a.json"}, "options": {"tabSize": 2, "insertSpaces": true, "eol": "\r\n"}}}`,
+	)
+	if len(got) != 2 {
+		t.Fatalf("got %d messages, want 2", len(got))
+	}
+	checkMessage(t, got[1], "", `[
+		{"range": {"start": {"line": 0, "character": 1}, "end": {"line": 0, "character": 1}}, "newText": "\r\n  "},
+		{"range": {"start": {"line": 0, "character": 7}, "end": {"line": 0, "character": 7}}, "newText": "\r\n"}
+	]`)
+}
+
+func TestServer_foldingRange(t *testing.T) {
+	got := session(t,
+		didOpen("{\n  /*\n  */\n  \"a\": [\n    1\n  ]\n}"),
+		`{"jsonrpc": "2.0", "id": 1, "method": "textDocument/foldingRange", "params": {"textDocument": {"uri": "file:///a.json"}}}`,
+	)
+	if len(got) != 2 {
+		t.Fatalf("got %d messages, want 2", len(got))
+	}
+	checkMessage(t, got[1], "", `[
+		{"startLine": 0, "endLine": 5},
+		{"startLine": 1, "endLine": 2, "kind": "comment"},
+		{"startLine": 3, "endLine": 4}
+	]`)
+}
+
+func TestServer_documentSymbol(t *testing.T) {
+	got := session(t,
+		didOpen("{\n  \"a\": [\n    \"😀\"\n  ]\n}"),
+		`{"jsonrpc": "2.0", "id": 1, "method": "textDocument/documentSymbol", "params": {"textDocument": {"uri": "file:///a.json"}}}`,
+	)
+	if len(got) != 2 {
+		t.Fatalf("got %d messages, want 2", len(got))
+	}
+	checkMessage(t, got[1], "", `[
+		{
+			"name": "a",
+			"kind": 18,
+			"range": {"start": {"line": 1, "character": 2}, "end": {"line": 3, "character": 3}},
+			"selectionRange": {"start": {"line": 1, "character": 2}, "end": {"line": 1, "character": 5}},
+			"children": [
+				{
+					"name": "0",
+					"detail": "\"😀\"",
+					"kind": 15,
+					"range": {"start": {"line": 2, "character": 4}, "end": {"line": 2, "character": 8}},
+					"selectionRange": {"start": {"line": 2, "character": 4}, "end": {"line": 2, "character": 8}}
+				}
+			]
+		}
+	]`)
+}
+
+func TestServer_selectionRange(t *testing.T) {
+	got := session(t,
+		didOpen("{\n  \"😀\": 1\n}"),
+		`{"jsonrpc": "2.0", "id": 1, "method": "textDocument/selectionRange", "params": {"textDocument": {"uri": "file:///a.json"}, "positions": [{"line": 1, "character": 8}]}}`,
+	)
+	if len(got) != 2 {
+		t.Fatalf("got %d messages, want 2", len(got))
+	}
+	checkMessage(t, got[1], "", `[
+		{
+			"range": {"start": {"line": 1, "character": 8}, "end": {"line": 1, "character": 9}},
+			"parent": {
+				"range": {"start": {"line": 1, "character": 2}, "end": {"line": 1, "character": 9}},
+				"parent": {
+					"range": {"start": {"line": 0, "character": 1}, "end": {"line": 2, "character": 0}},
+					"parent": {
+						"range": {"start": {"line": 0, "character": 0}, "end": {"line": 2, "character": 1}}
+					}
+				}
+			}
+		}
+	]`)
+}
+
+func TestServer_notInitialized(t *testing.T) {
+	var in, out bytes.Buffer
+	msg := `{"jsonrpc": "2.0", "id": 1, "method": "textDocument/formatting", "params": {}}`
+	fmt.Fprintf(&in, "Content-Length: %d\r\n\r\n%s", len(msg), msg)
+	newServer(&in, &out, nil).serve()
+	got, err := newConn(&out, nil).read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Error == nil || got.Error.Code != codeServerNotInitialized || !strings.Contains(got.Error.Message, "not initialized") {
+		t.Errorf("got error %v, want code %d", got.Error, codeServerNotInitialized)
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/sourcegraph/jsonx"
+)
+
+var parseOptions = jsonx.ParseOptions{Comments: true, TrailingCommas: true}
+
+// get prints the value at a JSON pointer.
+func (c *command) get(args []string) int {
+	fs := c.flagSet("get", "[flags] <pointer> [file]")
+	raw := fs.Bool("r", false, "print strings without quotes and escapes")
+	if !c.parseFlags(fs, args, 1, 2) {
+		return exitUsage
+	}
+
+	name, text, err := c.readInput(fs.Arg(1))
+	if err != nil {
+		return c.fail(err)
+	}
+	root, _ := jsonx.ParseTree(text, parseOptions)
+	path, err := parsePointer(root, fs.Arg(0))
+	if err != nil {
+		return c.fail(err)
+	}
+	node := jsonx.FindNodeAtLocation(root, path)
+	if node == nil {
+		return c.fail(fmt.Errorf("%s: %s not found", name, fs.Arg(0)))
+	}
+	if *raw && node.Type == jsonx.String {
+		fmt.Fprintln(c.stdout, node.Value)
+	} else {
+		fmt.Fprintln(c.stdout, string([]rune(text)[node.Offset:node.Offset+node.Length]))
+	}
+	return exitOK
+}
+
+// set sets the value at a JSON pointer.
+func (c *command) set(args []string) int {
+	fs := c.flagSet("set", "[flags] <pointer> <json> [file]")
+	write := fs.Bool("w", false, "write the result to the file instead of standard output")
+	if !c.parseFlags(fs, args, 2, 3) {
+		return exitUsage
+	}
+	value := fs.Arg(1)
+	if !json.Valid([]byte(value)) { // the value is inserted as is, so it must be strict JSON
+		return c.fail(fmt.Errorf("set: invalid JSON value %q", value))
+	}
+	return c.edit(fs.Arg(0), json.RawMessage(value), fs.Arg(2), *write)
+}
+
+// rm removes the value at a JSON pointer.
+func (c *command) rm(args []string) int {
+	fs := c.flagSet("rm", "[flags] <pointer> [file]")
+	write := fs.Bool("w", false, "write the result to the file instead of standard output")
+	if !c.parseFlags(fs, args, 1, 2) {
+		return exitUsage
+	}
+	if fs.Arg(0) == "" {
+		return c.fail(errors.New("rm: cannot remove the root value"))
+	}
+	return c.edit(fs.Arg(0), nil, fs.Arg(1), *write)
+}
+
+// edit sets the value at the JSON pointer in the file, or removes it if the value
+// is nil, preserving the comments and formatting of the document.
+func (c *command) edit(pointer string, value json.RawMessage, file string, write bool) int {
+	if write && file == "" {
+		return c.fail(errors.New("cannot use -w with standard input"))
+	}
+	name, text, err := c.readInput(file)
+	if err != nil {
+		return c.fail(err)
+	}
+	root, parseErrors := jsonx.ParseTree(text, parseOptions)
+	if len(parseErrors) > 0 {
+		return c.fail(fmt.Errorf("%s: invalid document (see jsonx validate)", name))
+	}
+	path, err := parsePointer(root, pointer)
+	if err != nil {
+		return c.fail(err)
+	}
+
+	// Zero format options detect the formatting of the document.
+	var edits []jsonx.Edit
+	if value == nil {
+		edits, _, err = jsonx.ComputePropertyRemoval(text, path, jsonx.FormatOptions{})
+	} else {
+		edits, _, err = jsonx.ComputePropertyEdit(text, path, value, nil, jsonx.FormatOptions{})
+	}
+	if err != nil {
+		return c.fail(fmt.Errorf("%s: %w", name, err))
+	}
+	result, err := jsonx.ApplyEdits(text, edits...)
+	if err != nil {
+		return c.fail(fmt.Errorf("%s: %w", name, err))
+	}
+	if err := c.writeOutput(name, result, write); err != nil {
+		return c.fail(err)
+	}
+	return exitOK
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/sourcegraph/jsonx"
+)
+
+var styles = map[string]jsonx.FormatStyle{
+	"pretty":  jsonx.PrettyStyle,
+	"compact": jsonx.CompactStyle,
+	"minify":  jsonx.MinifyStyle,
+}
+
+// fmt formats documents.
+func (c *command) fmt(args []string) int {
+	fs := c.flagSet("fmt", "[flags] [files]")
+	write := fs.Bool("w", false, "write the result to the files instead of standard output")
+	check := fs.Bool("check", false, "list the files that are not formatted instead of formatting them, and exit with status 1 if there are any")
+	indent := fs.Int("indent", 0, "indent with `n` spaces (by default, the indentation of each document is detected)")
+	tabs := fs.Bool("tabs", false, "indent with tabs")
+	style := fs.String("style", "pretty", "the layout of arrays and objects: pretty, compact or minify")
+	sortKeys := fs.Bool("sort-keys", false, "sort the properties of objects by key")
+	if !c.parseFlags(fs, args, 0, -1) {
+		return exitUsage
+	}
+
+	options := jsonx.FormatOptions{
+		TabSize:      *indent,
+		InsertSpaces: *indent != 0 && !*tabs,
+		SortKeys:     *sortKeys,
+	}
+	if *tabs && options.TabSize == 0 {
+		options.TabSize = 4 // don't detect the indentation
+	}
+	var ok bool
+	if options.Style, ok = styles[*style]; !ok {
+		fmt.Fprintf(c.stderr, "jsonx fmt: unknown style %q\n", *style)
+		return exitUsage
+	}
+	if *write && *check {
+		fmt.Fprintln(c.stderr, "jsonx fmt: -w and -check are mutually exclusive")
+		return exitUsage
+	}
+
+	files := fs.Args()
+	if len(files) == 0 {
+		if *write {
+			return c.fail(errors.New("fmt: cannot use -w with standard input"))
+		}
+		files = []string{""}
+	}
+	status := exitOK
+	for _, file := range files {
+		name, text, err := c.readInput(file)
+		if err != nil {
+			status = c.fail(err)
+			continue
+		}
+		options.InsertFinalNewline = jsonx.DetectFormatOptions(text).InsertFinalNewline // keep the final newline as it is
+		if *check {
+			if !jsonx.IsFormatted(text, options) {
+				fmt.Fprintln(c.stdout, name)
+				status = exitFail
+			}
+			continue
+		}
+		formatted, err := jsonx.FormatDocument(text, options)
+		if err != nil {
+			status = c.fail(fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+		if *write && formatted == text {
+			continue
+		}
+		if err := c.writeOutput(name, formatted, *write); err != nil {
+			status = c.fail(err)
+		}
+	}
+	return status
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"github.com/sourcegraph/jsonx"
+	"github.com/sourcegraph/jsonx/gostruct"
+)
+
+// gostruct generates Go types for the values of a sample document.
+func (c *command) gostruct(args []string) int {
+	fs := c.flagSet("gostruct", "[flags] [file]")
+	typeName := fs.String("type", "Config", "the `name` of the type of the root value")
+	pkg := fs.String("package", "", "generate a package clause for the package `name`")
+	if !c.parseFlags(fs, args, 0, 1) {
+		return exitUsage
+	}
+
+	name, text, err := c.readInput(fs.Arg(0))
+	if err != nil {
+		return c.fail(err)
+	}
+	if _, errors := jsonx.ParseWithDetailedErrors(text, parseOptions); len(errors) > 0 {
+		c.printErrors(name, text, errors)
+		return exitFail
+	}
+	src, err := gostruct.Generate(text, gostruct.Options{TypeName: *typeName, Package: *pkg})
+	if err != nil {
+		return c.fail(err)
+	}
+	if err := c.writeOutput(name, string(src), false); err != nil {
+		return c.fail(err)
+	}
+	return exitOK
+}
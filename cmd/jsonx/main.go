@@ -0,0 +1,168 @@
+// Command jsonx formats, validates and edits JSON documents with comments and
+// trailing commas (JSONC).
+//
+// Usage:
+//
+//	jsonx <command> [flags] [arguments]
+//
+// The commands are:
+//
+//	fmt [-w | -check] [files]    format documents
+//	validate [-strict] [files]   report syntax errors as file:line:col: error
+//	get [-r] <pointer> [file]    print the value at a JSON pointer
+//	set [-w] <pointer> <json> [file]
+//	                             set the value at a JSON pointer
+//	rm [-w] <pointer> [file]     remove the value at a JSON pointer
+//	strip [-indent n] [file]     convert a document to strict JSON
+//	gostruct [-type name] [-package name] [file]
+//	                             generate Go types for a sample document
+//
+// Commands read from standard input if no file is given, and write the result
+// to standard output unless -w is given. Edits preserve comments and
+// formatting. Values are addressed with JSON pointers (RFC 6901), such as
+// /compilerOptions/paths/0, where - refers to the end of an array.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+const usage = `usage: jsonx <command> [flags] [arguments]
+
+commands:
+  fmt [-w | -check] [files]    format documents
+  validate [-strict] [files]   report syntax errors as file:line:col: error
+  get [-r] <pointer> [file]    print the value at a JSON pointer
+  set [-w] <pointer> <json> [file]
+                               set the value at a JSON pointer
+  rm [-w] <pointer> [file]     remove the value at a JSON pointer
+  strip [-indent n] [file]     convert a document to strict JSON
+  gostruct [-type name] [-package name] [file]
+                               generate Go types for a sample document
+
+Run 'jsonx <command> -h' for the flags of a command.
+`
+
+// exit codes
+const (
+	exitOK    = 0
+	exitFail  = 1 // the command failed, or found errors or unformatted files
+	exitUsage = 2
+)
+
+type command struct {
+	stdin          io.Reader
+	stdout, stderr io.Writer
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return exitUsage
+	}
+	c := &command{stdin: stdin, stdout: stdout, stderr: stderr}
+	commands := map[string]func([]string) int{
+		"fmt":      c.fmt,
+		"validate": c.validate,
+		"get":      c.get,
+		"set":      c.set,
+		"rm":       c.rm,
+		"strip":    c.strip,
+		"gostruct": c.gostruct,
+	}
+	if args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		fmt.Fprint(stdout, usage)
+		return exitOK
+	}
+	f, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "jsonx: unknown command %q\n\n%s", args[0], usage)
+		return exitUsage
+	}
+	return f(args[1:])
+}
+
+// flagSet returns a flag set for the named command that reports errors to stderr.
+func (c *command) flagSet(name, arguments string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(c.stderr, "usage: jsonx %s %s\n", name, arguments)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseFlags parses the command's flags and checks that the number of remaining
+// arguments is within [minArgs, maxArgs] (unlimited if maxArgs < 0). It returns
+// false if the command should exit with a usage error.
+func (c *command) parseFlags(fs *flag.FlagSet, args []string, minArgs, maxArgs int) bool {
+	if err := fs.Parse(args); err != nil {
+		return false
+	}
+	if n := fs.NArg(); n < minArgs || maxArgs >= 0 && n > maxArgs {
+		fs.Usage()
+		return false
+	}
+	return true
+}
+
+// readInput returns the name and content of the file, or of the standard input if
+// the name is empty.
+func (c *command) readInput(name string) (string, string, error) {
+	if name == "" {
+		data, err := io.ReadAll(c.stdin)
+		return "<stdin>", string(data), err
+	}
+	data, err := os.ReadFile(name)
+	return name, string(data), err
+}
+
+// writeOutput writes the content to the file if inPlace, or to the standard output
+// otherwise.
+func (c *command) writeOutput(name, content string, inPlace bool) error {
+	if !inPlace {
+		_, err := io.WriteString(c.stdout, content)
+		return err
+	}
+	info, err := os.Stat(name)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(name, []byte(content), info.Mode().Perm())
+}
+
+// fail reports the error and returns the failure exit code.
+func (c *command) fail(err error) int {
+	fmt.Fprintf(c.stderr, "jsonx: %s\n", err)
+	return exitFail
+}
+
+// position returns the 1-based line and column (in characters) of the character
+// offset in the text.
+func position(text string, offset int) (line, column int) {
+	line, column = 1, 1
+	prevCR := false
+	for i, ch := range []rune(text) {
+		if i == offset {
+			break
+		}
+		switch {
+		case ch == '\n' && prevCR:
+			// the line break was counted at the '\r'
+		case ch == '\n' || ch == '\r':
+			line, column = line+1, 1
+		default:
+			column++
+		}
+		prevCR = ch == '\r'
+	}
+	return line, column
+}
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sourcegraph/jsonx"
+)
+
+const testDocument = `{
+  // comment
+  "a": [1, 2],
+  "b": {"c": "x\ty",},
+}
+`
+
+func TestRun(t *testing.T) {
+	tests := map[string]struct {
+		args       []string
+		stdin      string
+		wantStdout string
+		wantStderr string
+		wantStatus int
+	}{
+		"no command": {
+			wantStderr: usage,
+			wantStatus: exitUsage,
+		},
+		"unknown command": {
+			args:       []string{"x"},
+			wantStderr: "jsonx: unknown command \"x\"\n\n" + usage,
+			wantStatus: exitUsage,
+		},
+		"fmt": {
+			args:       []string{"fmt"},
+			stdin:      testDocument,
+			wantStdout: "{\n  // comment\n  \"a\": [\n    1,\n    2\n  ],\n  \"b\": {\n    \"c\": \"x\\ty\",\n  },\n}\n",
+		},
+		"fmt - flags": {
+			args:       []string{"fmt", "-style", "minify", "-sort-keys"},
+			stdin:      `{"b": 1, "a": [1, 2]}`,
+			wantStdout: `{"a":[1,2],"b":1}`,
+		},
+		"fmt - check": {
+			args:       []string{"fmt", "-check"},
+			stdin:      testDocument,
+			wantStdout: "<stdin>\n",
+			wantStatus: exitFail,
+		},
+		"fmt - check formatted": {
+			args:  []string{"fmt", "-check"},
+			stdin: "{\n  \"a\": 1\n}\n",
+		},
+		"fmt - unknown style": {
+			args:       []string{"fmt", "-style", "x"},
+			wantStderr: "jsonx fmt: unknown style \"x\"\n",
+			wantStatus: exitUsage,
+		},
+		"validate": {
+			args:  []string{"validate"},
+			stdin: testDocument,
+		},
+		"validate - strict": {
+			args:       []string{"validate", "-strict"},
+			stdin:      "{\n  \"a\": [1,],\n  // c\n}",
+			wantStdout: "<stdin>:2:11: ValueExpected\n<stdin>:3:3: InvalidCommentToken\n<stdin>:4:1: PropertyNameExpected\n<stdin>:4:1: ValueExpected\n",
+			wantStatus: exitFail,
+		},
+		"get": {
+			args:       []string{"get", "/a/1"},
+			stdin:      testDocument,
+			wantStdout: "2\n",
+		},
+		"get - object": {
+			args:       []string{"get", "/b"},
+			stdin:      testDocument,
+			wantStdout: "{\"c\": \"x\\ty\",}\n",
+		},
+		"get - raw": {
+			args:       []string{"get", "-r", "/b/c"},
+			stdin:      testDocument,
+			wantStdout: "x\ty\n",
+		},
+		"get - not found": {
+			args:       []string{"get", "/a/2"},
+			stdin:      testDocument,
+			wantStderr: "jsonx: <stdin>: /a/2 not found\n",
+			wantStatus: exitFail,
+		},
+		"set": {
+			args:       []string{"set", "/b/d", "[true]"},
+			stdin:      "{\n  // comment\n  \"b\": {\n    \"c\": 1\n  }\n}\n",
+			wantStdout: "{\n  // comment\n  \"b\": {\n    \"c\": 1,\n    \"d\": [\n      true\n    ]\n  }\n}\n",
+		},
+		"set - append": {
+			args:       []string{"set", "/a/-", "3"},
+			stdin:      "{\n\t\"a\": [\n\t\t1\n\t]\n}",
+			wantStdout: "{\n\t\"a\": [\n\t\t1,\n\t\t3\n\t]\n}",
+		},
+		"set - invalid value": {
+			args:       []string{"set", "/a", "{"},
+			stdin:      testDocument,
+			wantStderr: "jsonx: set: invalid JSON value \"{\"\n",
+			wantStatus: exitFail,
+		},
+		"set - JSONC value": {
+			args:       []string{"set", "/a", "[1,]"},
+			stdin:      testDocument,
+			wantStderr: "jsonx: set: invalid JSON value \"[1,]\"\n",
+			wantStatus: exitFail,
+		},
+		"set - invalid document": {
+			args:       []string{"set", "/a", "1"},
+			stdin:      "{",
+			wantStderr: "jsonx: <stdin>: invalid document (see jsonx validate)\n",
+			wantStatus: exitFail,
+		},
+		"rm": {
+			args:       []string{"rm", "/a/0"},
+			stdin:      "{\n  // comment\n  \"a\": [\n    1,\n    2\n  ]\n}\n",
+			wantStdout: "{\n  // comment\n  \"a\": [\n    2\n  ]\n}\n",
+		},
+		"rm - index out of range": {
+			args:       []string{"rm", "/a/5"},
+			stdin:      testDocument,
+			wantStderr: "jsonx: <stdin>: " + (&jsonx.IndexOutOfRangeError{Path: jsonx.MakePath("a", 5), Index: 5, Len: 2}).Error() + "\n",
+			wantStatus: exitFail,
+		},
+		"rm - root": {
+			args:       []string{"rm", ""},
+			stdin:      testDocument,
+			wantStderr: "jsonx: rm: cannot remove the root value\n",
+			wantStatus: exitFail,
+		},
+		"strip": {
+			args:       []string{"strip"},
+			stdin:      testDocument,
+			wantStdout: "{\"a\":[1,2],\"b\":{\"c\":\"x\\ty\"}}\n",
+		},
+		"strip - indent": {
+			args:       []string{"strip", "-indent", "1"},
+			stdin:      `[1,]`,
+			wantStdout: "[\n 1\n]\n",
+		},
+		"strip - invalid document": {
+			args:       []string{"strip"},
+			stdin:      "[1 2]",
+			wantStdout: "<stdin>:1:4: CommaExpected\n",
+			wantStatus: exitFail,
+		},
+		"gostruct": {
+			args:       []string{"gostruct", "-type", "Doc", "-package", "p"},
+			stdin:      testDocument,
+			wantStdout: "package p\n\ntype Doc struct {\n\t// comment\n\tA []int `json:\"a\"`\n\tB B     `json:\"b\"`\n}\n\ntype B struct {\n\tC string `json:\"c\"`\n}\n",
+		},
+		"gostruct - invalid document": {
+			args:       []string{"gostruct"},
+			stdin:      "[1 2]",
+			wantStdout: "<stdin>:1:4: CommaExpected\n",
+			wantStatus: exitFail,
+		},
+	}
+	for label, test := range tests {
+		t.Run(label, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			status := run(test.args, strings.NewReader(test.stdin), &stdout, &stderr)
+			if status != test.wantStatus {
+				t.Errorf("got status %d, want %d", status, test.wantStatus)
+			}
+			if got := stdout.String(); got != test.wantStdout {
+				t.Errorf("stdout\ngot  %q\nwant %q", got, test.wantStdout)
+			}
+			if got := stderr.String(); got != test.wantStderr {
+				t.Errorf("stderr\ngot  %q\nwant %q", got, test.wantStderr)
+			}
+		})
+	}
+}
+
+func TestRun_inPlace(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "a.json")
+	if err := os.WriteFile(name, []byte(`{"a": 1}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, args := range [][]string{
+		{"set", "-w", "/b", `"x"`, name},
+		{"rm", "-w", "/a", name},
+		{"fmt", "-w", "-indent", "4", name},
+	} {
+		var stdout, stderr bytes.Buffer
+		if status := run(args, nil, &stdout, &stderr); status != exitOK {
+			t.Fatalf("%v: got status %d (%s)", args, status, stderr.String())
+		}
+		if stdout.Len() > 0 {
+			t.Errorf("%v: got stdout %q, want none", args, stdout.String())
+		}
+	}
+
+	data, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(data), "{\n    \"b\": \"x\"\n}"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestParsePointer(t *testing.T) {
+	root, _ := jsonx.ParseTree(`{"a": [{"b": 1}], "c~/": {"0": 2}}`, parseOptions)
+	tests := map[string]struct {
+		pointer string
+		want    jsonx.Path
+		wantErr bool
+	}{
+		"root":          {pointer: "", want: jsonx.Path{}},
+		"property":      {pointer: "/a", want: jsonx.MakePath("a")},
+		"index":         {pointer: "/a/0/b", want: jsonx.MakePath("a", 0, "b")},
+		"end of array":  {pointer: "/a/-", want: jsonx.MakePath("a", -1)},
+		"escapes":       {pointer: "/c~0~1/0", want: jsonx.MakePath("c~/", "0")},
+		"missing":       {pointer: "/x/0", want: jsonx.MakePath("x", "0")},
+		"no slash":      {pointer: "a", wantErr: true},
+		"invalid index": {pointer: "/a/01", wantErr: true},
+	}
+	for label, test := range tests {
+		t.Run(label, func(t *testing.T) {
+			got, err := parsePointer(root, test.pointer)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("got error %v, want error %v", err, test.wantErr)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %s, want %s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestPosition(t *testing.T) {
+	text := "a\nb\r\ncd\ré"
+	tests := []struct {
+		offset       int
+		line, column int
+	}{
+		{0, 1, 1},
+		{1, 1, 2},
+		{2, 2, 1},
+		{5, 3, 1},
+		{6, 3, 2},
+		{8, 4, 1},
+		{9, 4, 2},
+	}
+	for _, test := range tests {
+		if line, column := position(text, test.offset); line != test.line || column != test.column {
+			t.Errorf("offset %d: got %d:%d, want %d:%d", test.offset, line, column, test.line, test.column)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/sourcegraph/jsonx"
+)
+
+var pointerUnescaper = strings.NewReplacer("~1", "/", "~0", "~")
+
+// parsePointer returns the key path of the JSON pointer (RFC 6901) in the document
+// with the parse tree root. A reference token is an array index if it refers to an
+// element of an existing array (where - refers to the end of the array, as index
+// -1), and an object property otherwise.
+func parsePointer(root *jsonx.Node, pointer string) (jsonx.Path, error) {
+	if pointer == "" {
+		return jsonx.Path{}, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid JSON pointer %q: must be empty or start with /", pointer)
+	}
+	var path jsonx.Path
+	node := root
+	for _, token := range strings.Split(pointer[1:], "/") {
+		token = pointerUnescaper.Replace(token)
+		segment := jsonx.Segment{IsProperty: true, Property: token}
+		if node != nil && node.Type == jsonx.Array {
+			index, err := parseArrayIndex(token)
+			if err != nil {
+				return nil, fmt.Errorf("invalid JSON pointer %q: %w", pointer, err)
+			}
+			segment = jsonx.Segment{Index: index}
+		}
+		path = append(path, segment)
+		node = jsonx.FindNodeAtLocation(node, jsonx.Path{segment})
+	}
+	return path, nil
+}
+
+// parseArrayIndex returns the array index of the reference token.
+func parseArrayIndex(token string) (int, error) {
+	if token == "-" {
+		return -1, nil
+	}
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 || token != strconv.Itoa(index) {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	return index, nil
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/sourcegraph/jsonx"
+)
+
+// strip converts a document to strict JSON, without comments and trailing commas.
+func (c *command) strip(args []string) int {
+	fs := c.flagSet("strip", "[flags] [file]")
+	indent := fs.Int("indent", 0, "indent the output with `n` spaces (by default, the output has no whitespace)")
+	if !c.parseFlags(fs, args, 0, 1) {
+		return exitUsage
+	}
+
+	name, text, err := c.readInput(fs.Arg(0))
+	if err != nil {
+		return c.fail(err)
+	}
+	data, errors := jsonx.ParseWithDetailedErrors(text, parseOptions)
+	if len(errors) > 0 {
+		c.printErrors(name, text, errors)
+		return exitFail
+	}
+	if *indent > 0 {
+		var buf bytes.Buffer
+		if err := json.Indent(&buf, data, "", strings.Repeat(" ", *indent)); err != nil {
+			return c.fail(err)
+		}
+		data = buf.Bytes()
+	}
+	if err := c.writeOutput(name, string(data)+"\n", false); err != nil {
+		return c.fail(err)
+	}
+	return exitOK
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/sourcegraph/jsonx"
+)
+
+// validate reports the syntax errors of documents.
+func (c *command) validate(args []string) int {
+	fs := c.flagSet("validate", "[flags] [files]")
+	strict := fs.Bool("strict", false, "report comments and trailing commas as errors")
+	if !c.parseFlags(fs, args, 0, -1) {
+		return exitUsage
+	}
+
+	files := fs.Args()
+	if len(files) == 0 {
+		files = []string{""}
+	}
+	options := jsonx.ParseOptions{Comments: !*strict, TrailingCommas: !*strict}
+	status := exitOK
+	for _, file := range files {
+		name, text, err := c.readInput(file)
+		if err != nil {
+			status = c.fail(err)
+			continue
+		}
+		if _, errors := jsonx.ParseWithDetailedErrors(text, options); len(errors) > 0 {
+			c.printErrors(name, text, errors)
+			status = exitFail
+		}
+	}
+	return status
+}
+
+// printErrors prints the parse errors of the document to the standard output, one
+// per line, as file:line:col: error.
+func (c *command) printErrors(name, text string, errors []jsonx.ParseError) {
+	for _, err := range errors {
+		line, column := position(text, err.Offset)
+		fmt.Fprintf(c.stdout, "%s:%d:%d: %s\n", name, line, column, err.Code)
+	}
+}
@@ -7,8 +7,10 @@ package jsonx
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
 )
 
 // An Edit represents an edit to a JSON document.
@@ -60,6 +62,7 @@ func computePropertyEdit(text string, path Path, valueObj interface{}, insertion
 
 	root, parseErrorCodes := ParseTree(text, ParseOptions{Comments: true, TrailingCommas: true})
 
+	fullPath := path
 	var parent *Node
 
 	var lastSegment Segment
@@ -84,10 +87,13 @@ func computePropertyEdit(text string, path Path, valueObj interface{}, insertion
 	}
 
 	if parent == nil {
-		// empty document
 		if valueObj == nil { // delete
-			return nil, nil, errors.New("can't delete in empty document")
+			if root != nil && len(fullPath) > 0 {
+				return nil, parseErrorCodes, &PathNotFoundError{Path: fullPath}
+			}
+			return nil, parseErrorCodes, &EmptyDocumentError{} // the root value has no parent to remove it from
 		}
+		// empty document
 		edit := Edit{Content: value}
 		if root != nil {
 			edit.Offset = root.Offset
@@ -148,6 +154,9 @@ func computePropertyEdit(text string, path Path, valueObj interface{}, insertion
 		var index int
 		if insertionIndex != nil {
 			index = insertionIndex(ObjectPropertyNames(*parent))
+			if index < 0 || index > len(parent.Children) {
+				return nil, parseErrorCodes, &IndexOutOfRangeError{Path: fullPath, Index: index, Len: len(parent.Children)}
+			}
 		} else {
 			index = len(parent.Children)
 		}
@@ -166,7 +175,7 @@ func computePropertyEdit(text string, path Path, valueObj interface{}, insertion
 		return edits, parseErrorCodes, err
 	} else if parent.Type == Array && !lastSegment.IsProperty {
 		insertIndex := lastSegment
-		if insertIndex.Index == -1 {
+		if insertIndex.Index == -1 && valueObj != nil {
 			// Insert
 			var edit Edit
 			if len(parent.Children) == 0 {
@@ -179,7 +188,11 @@ func computePropertyEdit(text string, path Path, valueObj interface{}, insertion
 			return edits, parseErrorCodes, err
 		}
 
-		if valueObj == nil && len(parent.Children) >= 0 {
+		if lastSegment.Index < 0 || lastSegment.Index >= len(parent.Children) {
+			return nil, parseErrorCodes, &IndexOutOfRangeError{Path: fullPath, Index: lastSegment.Index, Len: len(parent.Children)}
+		}
+
+		if valueObj == nil {
 			// Removal
 			removalIndex := lastSegment.Index
 			toRemove := parent.Children[removalIndex]
@@ -208,34 +221,122 @@ func computePropertyEdit(text string, path Path, valueObj interface{}, insertion
 		return edits, parseErrorCodes, err
 	}
 
+	return nil, parseErrorCodes, &TypeMismatchError{Path: fullPath, Segment: lastSegment, ParentType: parent.Type}
+}
+
+// PathNotFoundError is returned when an edit refers to a key path whose
+// parent does not exist in the JSON document.
+type PathNotFoundError struct {
+	Path Path // the key path that could not be resolved
+}
+
+func (e *PathNotFoundError) Error() string {
+	return fmt.Sprintf("path %s not found", e.Path)
+}
+
+// IndexOutOfRangeError is returned when an edit refers to an array index (or
+// an insertion index) outside of the bounds of its parent.
+type IndexOutOfRangeError struct {
+	Path  Path // the key path of the edit
+	Index int  // the out-of-range index
+	Len   int  // the number of elements in the parent
+}
+
+func (e *IndexOutOfRangeError) Error() string {
+	return fmt.Sprintf("index %d out of range [0:%d] at path %s", e.Index, e.Len, e.Path)
+}
+
+// TypeMismatchError is returned when an edit's key path segment does not match
+// the type of its parent node (e.g., a property segment whose parent is an array).
+type TypeMismatchError struct {
+	Path       Path     // the key path of the edit
+	Segment    Segment  // the last segment of the key path
+	ParentType NodeType // the type of the existing parent node
+}
+
+func (e *TypeMismatchError) Error() string {
 	var noun string
-	if lastSegment.IsProperty {
+	if e.Segment.IsProperty {
 		noun = "property"
 	} else {
 		noun = "index"
 	}
-	return nil, nil, fmt.Errorf("can't add %s to parent of type %s", noun, parent.Type)
+	return fmt.Sprintf("can't add %s to parent of type %s at path %s", noun, e.ParentType, e.Path)
+}
+
+// EmptyDocumentError is returned when removing a value from an empty JSON
+// document, or when removing the root value (with an empty key path).
+type EmptyDocumentError struct{}
+
+func (e *EmptyDocumentError) Error() string {
+	return "can't delete in empty document"
 }
 
 // ApplyEdits applies the edits to the JSON document and returns the edited
-// document. The edits must be ordered and within the bounds of the document.
+// document. The edits may be given in any order, but they must be within the
+// bounds of the document and must not overlap (in which case a *ConflictError is
+// returned). Multiple insertions (edits with zero length) at the same offset are
+// applied in the order given.
 //
 // Source: https://github.com/Microsoft/vscode/blob/c0bc1ace7ca3ce2d6b1aeb2bde9d1bb0f4b4bae6/src/vs/base/common/jsonFormatter.ts#L34
 func ApplyEdits(text string, edits ...Edit) (string, error) {
-	chars := []rune(text)
-	lastEditOffset := len(chars)
-	for i := len(edits) - 1; i >= 0; i-- {
-		edit := edits[i]
-		if edit.Offset < 0 || edit.Length < 0 && edit.Offset+edit.Length > len(chars) {
-			return "", fmt.Errorf("edit out of bounds: offset %d, length %d, doc length %d", edit.Offset, edit.Length, len(chars))
+	sorted, err := sortEdits(edits, utf8.RuneCountInString(text))
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	b.Grow(len(text))
+	var offset, pos int // character offset and byte position in text
+	advance := func(toOffset int) {
+		for ; offset < toOffset; offset++ {
+			_, size := utf8.DecodeRuneInString(text[pos:])
+			pos += size
 		}
-		if lastEditOffset < edit.Offset+edit.Length {
-			return "", fmt.Errorf("edit out of order: edit end offset %d exceeds next edit offset %d", edit.Offset+edit.Length, lastEditOffset)
+	}
+	for _, edit := range sorted {
+		start := pos
+		advance(edit.Offset)
+		b.WriteString(text[start:pos])
+		b.WriteString(edit.Content)
+		advance(edit.Offset + edit.Length)
+	}
+	b.WriteString(text[pos:])
+	return b.String(), nil
+}
+
+// sortEdits returns a copy of the edits sorted by offset, with insertions
+// preceding other edits at the same offset. It returns an error if an edit
+// is out of the bounds of a document of length n or if edits overlap.
+func sortEdits(edits []Edit, n int) ([]Edit, error) {
+	sorted := make([]Edit, len(edits))
+	copy(sorted, edits)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Offset != sorted[j].Offset {
+			return sorted[i].Offset < sorted[j].Offset
+		}
+		return sorted[i].Length == 0 && sorted[j].Length != 0
+	})
+	for i, edit := range sorted {
+		if edit.Offset < 0 || edit.Length < 0 || edit.Offset+edit.Length > n {
+			return nil, fmt.Errorf("edit out of bounds: offset %d, length %d, doc length %d", edit.Offset, edit.Length, n)
+		}
+		if i > 0 {
+			if previous := sorted[i-1]; edit.Offset < previous.Offset+previous.Length {
+				return nil, &ConflictError{First: previous, Second: edit}
+			}
 		}
-		lastEditOffset = edit.Offset
-		chars = []rune(string(chars[:edit.Offset]) + edit.Content + string(chars[edit.Offset+edit.Length:]))
 	}
-	return string(chars), nil
+	return sorted, nil
+}
+
+// ConflictError is returned by ApplyEdits when two edits overlap.
+type ConflictError struct {
+	First, Second Edit // the overlapping edits, in document order
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("conflicting edits: edit at offset %d, length %d overlaps edit at offset %d, length %d", e.Second.Offset, e.Second.Length, e.First.Offset, e.First.Length)
 }
 
 // FormatEdit returns the edits necessary to perform the original edit for maintaining the
@@ -243,34 +344,136 @@ func ApplyEdits(text string, edits ...Edit) (string, error) {
 //
 // Source: https://github.com/Microsoft/vscode/blob/c0bc1ace7ca3ce2d6b1aeb2bde9d1bb0f4b4bae6/src/vs/base/common/jsonEdit.ts#L122
 func FormatEdit(text string, edit Edit, options FormatOptions) ([]Edit, error) {
+	edits, _, err := FormatEditWithRange(text, edit, options)
+	return edits, err
+}
+
+// FormatEditWithRange is like FormatEdit, but it also returns the range of the
+// original edit's (formatted) content in the edited document.
+func FormatEditWithRange(text string, edit Edit, options FormatOptions) ([]Edit, Range, error) {
+	// detect the options from the document before the edit
+	options = detectUnsetFormatOptions(text, options)
+
 	// apply the edit
 	newText, err := ApplyEdits(text, edit)
 	if err != nil {
-		return nil, err
+		return nil, Range{}, err
 	}
 
 	// format the new text
 	begin := edit.Offset
 	end := edit.Offset + len([]rune(edit.Content))
+	if n := len([]rune(newText)); end > n {
+		// Invalid UTF-8 sequences in the text and content can merge into fewer characters.
+		if begin > n {
+			begin = n
+		}
+		end = n
+	}
 	edits := FormatRange(newText, begin, end-begin, options)
+	formattedText, err := ApplyEdits(newText, edits...)
+	if err != nil {
+		return nil, Range{}, err
+	}
+	mapper, err := NewOffsetMapper(newText, edits...)
+	if err != nil {
+		return nil, Range{}, err
+	}
 
-	// apply the formatting edits and track the begin and end offsets of the changes
-	for i := len(edits) - 1; i >= 0; i-- {
-		edit := edits[i]
-		newText, err = ApplyEdits(newText, edit)
-		if err != nil {
-			return nil, err
+	// track the range of the original edit's content
+	contentBegin, _ := mapper.MapOffset(begin)
+	contentEnd := contentBegin
+	if end > begin {
+		last, deleted := mapper.MapOffset(end - 1)
+		if contentEnd = last; !deleted {
+			contentEnd++
 		}
+	}
+
+	// track the begin and end offsets of all changes
+	for _, edit := range edits {
 		if edit.Offset < begin {
 			begin = edit.Offset
 		}
 		if edit.Offset+edit.Length > end {
 			end = edit.Offset + edit.Length
 		}
-		end += len([]rune(edit.Content)) - edit.Length
 	}
+	end, _ = mapper.MapOffset(end)
 
 	// create a single edit with all changes
-	editLength := len([]rune(text)) - (len([]rune(newText)) - end) - begin
-	return []Edit{{Offset: begin, Length: editLength, Content: string(([]rune(newText))[begin:end])}}, nil
+	chars := []rune(formattedText)
+	editLength := len([]rune(text)) - (len(chars) - end) - begin
+	return []Edit{{Offset: begin, Length: editLength, Content: string(chars[begin:end])}}, Range{Offset: contentBegin, Length: contentEnd - contentBegin}, nil
+}
+
+// A Range is a range of characters in a JSON document.
+type Range struct {
+	Offset int // the character offset where the range begins
+	Length int // the character length of the range
+}
+
+// An OffsetMapper translates character offsets in a JSON document to offsets in
+// the document that results from applying edits to it, and vice versa. It is
+// useful for keeping positions (such as diagnostics, cursors and selections)
+// in sync with edits.
+type OffsetMapper struct {
+	edits []mappedEdit // ordered by offset
+}
+
+type mappedEdit struct {
+	old, new Range // the edit's range in the old and new document
+}
+
+// NewOffsetMapper returns an OffsetMapper for the edits to the JSON document
+// text. The edits must satisfy the same requirements as for ApplyEdits.
+func NewOffsetMapper(text string, edits ...Edit) (*OffsetMapper, error) {
+	sorted, err := sortEdits(edits, utf8.RuneCountInString(text))
+	if err != nil {
+		return nil, err
+	}
+	m := &OffsetMapper{edits: make([]mappedEdit, len(sorted))}
+	delta := 0
+	for i, edit := range sorted {
+		contentLength := len([]rune(edit.Content))
+		m.edits[i] = mappedEdit{
+			old: Range{Offset: edit.Offset, Length: edit.Length},
+			new: Range{Offset: edit.Offset + delta, Length: contentLength},
+		}
+		delta += contentLength - edit.Length
+	}
+	return m, nil
+}
+
+// MapOffset returns the offset in the edited document of the character at the
+// offset in the original document. If the character was removed or replaced by
+// an edit, it returns the offset of the edit's content and deleted is true.
+func (m *OffsetMapper) MapOffset(old int) (new int, deleted bool) {
+	// find the last edit that starts at or before old
+	i := sort.Search(len(m.edits), func(i int) bool { return m.edits[i].old.Offset > old }) - 1
+	if i < 0 {
+		return old, false
+	}
+	e := m.edits[i]
+	if old < e.old.Offset+e.old.Length {
+		return e.new.Offset, true
+	}
+	return old + (e.new.Offset + e.new.Length) - (e.old.Offset + e.old.Length), false
+}
+
+// UnmapOffset is the inverse of MapOffset. It returns the offset in the original
+// document of the character at the offset in the edited document. If the
+// character was inserted by an edit, it returns the offset of the edit in the
+// original document and inserted is true.
+func (m *OffsetMapper) UnmapOffset(new int) (old int, inserted bool) {
+	// find the last edit whose content starts at or before new
+	i := sort.Search(len(m.edits), func(i int) bool { return m.edits[i].new.Offset > new }) - 1
+	if i < 0 {
+		return new, false
+	}
+	e := m.edits[i]
+	if new < e.new.Offset+e.new.Length {
+		return e.old.Offset, true
+	}
+	return new - (e.new.Offset + e.new.Length) + (e.old.Offset + e.old.Length), false
 }
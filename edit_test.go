@@ -7,6 +7,8 @@ package jsonx
 
 import (
 	"encoding/json"
+	"errors"
+	"reflect"
 	"testing"
 )
 
@@ -386,4 +388,368 @@ func TestComputePropertyEdit(t *testing.T) {
 			},
 		})
 	})
+	t.Run("aligned values and comments", func(t *testing.T) {
+		alignOptions := defaultFormatOptions
+		alignOptions.AlignValues = true
+		alignOptions.AlignComments = true
+		assertEdits(t, []testCase{
+			{
+				input:   "{\n  \"a\":  1, // c\n  \"bb\": 2\n}",
+				path:    PropertyPath("long"),
+				value:   true,
+				options: &alignOptions,
+				want:    "{\n  \"a\":    1, // c\n  \"bb\":   2,\n  \"long\": true\n}",
+			},
+			{
+				input:   "{\n  \"a\":    1, // c\n  \"long\": 2 // d\n}",
+				path:    PropertyPath("long"),
+				value:   "xyz",
+				options: &alignOptions,
+				want:    "{\n  \"a\":    1,    // c\n  \"long\": \"xyz\" // d\n}",
+			},
+		})
+	})
+
+	t.Run("detected options", func(t *testing.T) {
+		assertEdits(t, []testCase{
+			{
+				input:   "{\r\n   \"a\": {\r\n      \"b\": 1\r\n   }\r\n}\r\n",
+				path:    PropertyPath("a", "c"),
+				value:   2,
+				options: &FormatOptions{},
+				want:    "{\r\n   \"a\": {\r\n      \"b\": 1,\r\n      \"c\": 2\r\n   }\r\n}\r\n",
+			},
+			{
+				input:   "{\n\t\"a\": 1\n}",
+				path:    PropertyPath("b"),
+				value:   []int{1},
+				options: &FormatOptions{},
+				want:    "{\n\t\"a\": 1,\n\t\"b\": [\n\t\t1\n\t]\n}",
+			},
+		})
+	})
+}
+
+func TestComputePropertyEdit_errors(t *testing.T) {
+	options := FormatOptions{TabSize: 2, InsertSpaces: true, EOL: "\n"}
+	tests := map[string]struct {
+		input       string
+		path        Path
+		value       interface{}
+		remove      bool
+		insertIndex func(properties []string) int
+		want        error
+	}{
+		"remove in empty document": {
+			input:  "",
+			path:   PropertyPath("x"),
+			remove: true,
+			want:   &EmptyDocumentError{},
+		},
+		"remove root value": {
+			input:  `{"a": 1}`,
+			path:   Path{},
+			remove: true,
+			want:   &EmptyDocumentError{},
+		},
+		"remove with missing parent": {
+			input:  `{"a": 1}`,
+			path:   PropertyPath("b", "c"),
+			remove: true,
+			want:   &PathNotFoundError{Path: PropertyPath("b", "c")},
+		},
+		"remove index out of range": {
+			input:  "[1, 2]",
+			path:   MakePath(10),
+			remove: true,
+			want:   &IndexOutOfRangeError{Path: MakePath(10), Index: 10, Len: 2},
+		},
+		"remove negative index": {
+			input:  "[1, 2]",
+			path:   MakePath(-1),
+			remove: true,
+			want:   &IndexOutOfRangeError{Path: MakePath(-1), Index: -1, Len: 2},
+		},
+		"set index out of range": {
+			input: "[1, 2]",
+			path:  MakePath(2),
+			value: 3,
+			want:  &IndexOutOfRangeError{Path: MakePath(2), Index: 2, Len: 2},
+		},
+		"set negative index": {
+			input: `{"a": [1]}`,
+			path:  MakePath("a", -5),
+			value: 3,
+			want:  &IndexOutOfRangeError{Path: MakePath("a", -5), Index: -5, Len: 1},
+		},
+		"insertion index out of range": {
+			input:       `{"a": 1}`,
+			path:        PropertyPath("b"),
+			value:       2,
+			insertIndex: func([]string) int { return 5 },
+			want:        &IndexOutOfRangeError{Path: PropertyPath("b"), Index: 5, Len: 1},
+		},
+		"property in array": {
+			input: "[1]",
+			path:  PropertyPath("a"),
+			value: 2,
+			want:  &TypeMismatchError{Path: PropertyPath("a"), Segment: Segment{IsProperty: true, Property: "a"}, ParentType: Array},
+		},
+		"index in object": {
+			input: `{"a": 1}`,
+			path:  MakePath(0),
+			value: 2,
+			want:  &TypeMismatchError{Path: MakePath(0), Segment: Segment{Index: 0}, ParentType: Object},
+		},
+	}
+	for label, test := range tests {
+		t.Run(label, func(t *testing.T) {
+			var err error
+			if test.remove {
+				_, _, err = ComputePropertyRemoval(test.input, test.path, options)
+			} else {
+				_, _, err = ComputePropertyEdit(test.input, test.path, test.value, test.insertIndex, options)
+			}
+			if err == nil {
+				t.Fatalf("got no error, want %v", test.want)
+			}
+			target := reflect.New(reflect.TypeOf(test.want))
+			if !errors.As(err, target.Interface()) {
+				t.Fatalf("got error %T (%v), want %T", err, err, test.want)
+			}
+			if got := target.Elem().Interface(); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got error %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestComputePropertyEdit_propertyWithoutValue(t *testing.T) {
+	const input = `{"a": 1, "b": }`
+	edits, _, err := ComputePropertyEdit(input, PropertyPath("c"), true, nil, FormatOptions{TabSize: 2, InsertSpaces: true, EOL: "\n"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	output, err := ApplyEdits(input, edits...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "{\n  \"a\": 1,\n  \"b\": ,\n  \"c\": true\n}"; output != want {
+		t.Errorf("got %q, want %q", output, want)
+	}
+}
+
+func FuzzComputePropertyEditPath(f *testing.F) {
+	documents := []string{
+		"",
+		"true",
+		"[]",
+		"{}",
+		"[\n  1,\n  2\n]",
+		"{\n  \"x\": \"y\", \"a\": [],\n}",
+		"{\n  \"x\": {\n    \"a\": 1,\n    \"b\": [true, {\"c\": null}]\n  }\n}\n",
+		"// This is a comment\n[\n  1,\n  \"foo\",\n  \"bar\",\n]",
+		`{"a": 1, "b": }`,
+		`[1, [2, [3`,
+	}
+	for _, input := range documents {
+		f.Add(input, `[]`, `1`, false)
+		f.Add(input, `[0]`, `"v"`, true)
+		f.Add(input, `["x"]`, `{"k": [1]}`, false)
+		f.Add(input, `["x", "b", 1]`, `null`, true)
+		f.Add(input, `[10]`, `2`, true)
+		f.Add(input, `[-1]`, `2`, false)
+		f.Add(input, `[-2, "a"]`, `2`, false)
+	}
+	for _, input := range fuzzSeedCorpus(f) {
+		f.Add(input, `["x"]`, `"v"`, false)
+		f.Add(input, `[0]`, `{"k": [1]}`, false)
+		f.Add(input, `["a", 1]`, ``, true)
+	}
+	f.Fuzz(func(t *testing.T, input, pathJSON, value string, remove bool) {
+		var path Path
+		if err := json.Unmarshal([]byte(pathJSON), &path); err != nil {
+			return
+		}
+		options := FormatOptions{TabSize: 2, InsertSpaces: true, EOL: "\n"}
+
+		var edits []Edit
+		var err error
+		if remove {
+			edits, _, err = ComputePropertyRemoval(input, path, options)
+		} else {
+			edits, _, err = ComputePropertyEdit(input, path, json.RawMessage(value), nil, options)
+		}
+		if err != nil {
+			var (
+				pathNotFound    *PathNotFoundError
+				indexOutOfRange *IndexOutOfRangeError
+				typeMismatch    *TypeMismatchError
+				emptyDocument   *EmptyDocumentError
+			)
+			if !errors.As(err, &pathNotFound) && !errors.As(err, &indexOutOfRange) && !errors.As(err, &typeMismatch) && !errors.As(err, &emptyDocument) {
+				t.Fatalf("%q at path %s: unexpected error type %T: %v", input, path, err, err)
+			}
+			return
+		}
+		if _, err := ApplyEdits(input, edits...); err != nil {
+			t.Fatalf("%q at path %s: ApplyEdits: %s", input, path, err)
+		}
+	})
+}
+
+func TestApplyEdits(t *testing.T) {
+	tests := map[string]struct {
+		input string
+		edits []Edit
+		want  string
+	}{
+		"no edits": {
+			input: `{"a": 1}`,
+			want:  `{"a": 1}`,
+		},
+		"ordered": {
+			input: `{"a": 1}`,
+			edits: []Edit{{Offset: 1, Length: 3, Content: `"b"`}, {Offset: 6, Length: 1, Content: "2"}},
+			want:  `{"b": 2}`,
+		},
+		"unordered": {
+			input: `{"a": 1}`,
+			edits: []Edit{{Offset: 6, Length: 1, Content: "2"}, {Offset: 1, Length: 3, Content: `"b"`}},
+			want:  `{"b": 2}`,
+		},
+		"insertions at same offset": {
+			input: "[]",
+			edits: []Edit{{Offset: 1, Content: "1"}, {Offset: 1, Content: ","}, {Offset: 1, Content: "2"}},
+			want:  "[1,2]",
+		},
+		"insertion before replacement at same offset": {
+			input: "[1]",
+			edits: []Edit{{Offset: 1, Length: 1, Content: "2"}, {Offset: 1, Content: "1,"}},
+			want:  "[1,2]",
+		},
+		"adjacent edits": {
+			input: "[1,2]",
+			edits: []Edit{{Offset: 2, Length: 1, Content: ", "}, {Offset: 1, Length: 1, Content: "3"}, {Offset: 3, Length: 1, Content: "4"}},
+			want:  "[3, 4]",
+		},
+		"unicode": {
+			input: `{"你": "好"}`,
+			edits: []Edit{{Offset: 7, Length: 1, Content: "世"}, {Offset: 2, Length: 1, Content: "a"}},
+			want:  `{"a": "世"}`,
+		},
+	}
+	for label, test := range tests {
+		t.Run(label, func(t *testing.T) {
+			output, err := ApplyEdits(test.input, test.edits...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if output != test.want {
+				t.Errorf("got %q, want %q", output, test.want)
+			}
+		})
+	}
+}
+
+func TestApplyEdits_errors(t *testing.T) {
+	t.Run("out of bounds", func(t *testing.T) {
+		for _, edit := range []Edit{
+			{Offset: -1, Length: 1},
+			{Offset: 1, Length: -1},
+			{Offset: 2, Length: 10},
+			{Offset: 9},
+		} {
+			if _, err := ApplyEdits(`{"a": 1}`, edit); err == nil {
+				t.Errorf("%+v: got no error, want out of bounds error", edit)
+			}
+		}
+	})
+	t.Run("conflict", func(t *testing.T) {
+		first := Edit{Offset: 1, Length: 3, Content: "x"}
+		second := Edit{Offset: 2, Content: "y"}
+		_, err := ApplyEdits(`{"a": 1}`, second, first)
+		var conflict *ConflictError
+		if !errors.As(err, &conflict) {
+			t.Fatalf("got error %v, want *ConflictError", err)
+		}
+		if want := (ConflictError{First: first, Second: second}); *conflict != want {
+			t.Errorf("got %+v, want %+v", *conflict, want)
+		}
+	})
+}
+
+func TestOffsetMapper(t *testing.T) {
+	const input = `{"a": 1, "b": [2, 3]}`
+	edits := []Edit{
+		{Offset: 14, Length: 6, Content: "[]"}, // replace [2, 3]
+		{Offset: 1, Content: `"x": 0, `},       // insert before "a"
+		{Offset: 6, Length: 1, Content: "10"},  // replace 1
+	}
+	output, err := ApplyEdits(input, edits...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"x": 0, "a": 10, "b": []}`; output != want {
+		t.Fatalf("got %q, want %q", output, want)
+	}
+
+	mapper, err := NewOffsetMapper(input, edits...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		old, new int
+		deleted  bool
+	}{
+		{old: 0, new: 0},
+		{old: 1, new: 9},                  // "a", shifted by the insertion
+		{old: 6, new: 14, deleted: true},  // 1, which was replaced
+		{old: 7, new: 16},                 // the comma after 1
+		{old: 15, new: 23, deleted: true}, // 2, which was replaced
+		{old: 20, new: 25},                // the closing brace
+		{old: 21, new: 26},                // the end of the document
+	} {
+		if new, deleted := mapper.MapOffset(test.old); new != test.new || deleted != test.deleted {
+			t.Errorf("MapOffset(%d): got (%d, %v), want (%d, %v)", test.old, new, deleted, test.new, test.deleted)
+		}
+	}
+	for _, test := range []struct {
+		new, old int
+		inserted bool
+	}{
+		{new: 0, old: 0},
+		{new: 1, old: 1, inserted: true},  // "x", which was inserted
+		{new: 9, old: 1},                  // "a"
+		{new: 15, old: 6, inserted: true}, // the second digit of 10
+		{new: 16, old: 7},                 // the comma after 10
+		{new: 25, old: 20},                // the closing brace
+	} {
+		if old, inserted := mapper.UnmapOffset(test.new); old != test.old || inserted != test.inserted {
+			t.Errorf("UnmapOffset(%d): got (%d, %v), want (%d, %v)", test.new, old, inserted, test.old, test.inserted)
+		}
+	}
+
+	if _, err := NewOffsetMapper(input, Edit{Offset: 20, Length: 2}); err == nil {
+		t.Error("got no error for an edit out of bounds, want an error")
+	}
+}
+
+func TestFormatEditWithRange(t *testing.T) {
+	const input = "{\n  \"a\": 1\n}"
+	edits, contentRange, err := FormatEditWithRange(input, Edit{Offset: 10, Content: `,"b": {"c":true}`}, FormatOptions{TabSize: 2, InsertSpaces: true, EOL: "\n"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	output, err := ApplyEdits(input, edits...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "{\n  \"a\": 1,\n  \"b\": {\n    \"c\": true\n  }\n}"; output != want {
+		t.Fatalf("got %q, want %q", output, want)
+	}
+	content := string([]rune(output)[contentRange.Offset : contentRange.Offset+contentRange.Length])
+	if want := ",\n  \"b\": {\n    \"c\": true\n  }"; content != want {
+		t.Errorf("got content range %+v (%q), want %q", contentRange, content, want)
+	}
 }
@@ -32,3 +32,18 @@ func ExampleEdit() {
 	//   },
 	// }
 }
+
+func ExampleReader() {
+	r := NewReader(`{"name": "jsonx", "tags": ["json", "jsonc"]}`, ParseOptions{})
+	for token := r.Next(); token.Kind != TokenEOF; token = r.Next() {
+		if token.Kind == TokenString {
+			fmt.Println(token.Path, token.StringValue())
+		}
+	}
+	if err := r.Err(); err != nil {
+		fmt.Println(err)
+	}
+	// Output: ["name"] jsonx
+	// ["tags",0] json
+	// ["tags",1] jsonc
+}
@@ -0,0 +1,165 @@
+// This file was ported from https://github.com/microsoft/vscode-json-languageservice/blob/main/src/services/jsonFolding.ts,
+// which is licensed as follows:
+//
+// Copyright (c) Microsoft Corporation. All rights reserved. Licensed under the MIT License.
+
+package jsonx
+
+import (
+	"regexp"
+	"sort"
+
+	"github.com/sourcegraph/jsonx/internal/lineindex"
+)
+
+// A FoldingRange is a range of lines in a JSON document that an editor can fold.
+type FoldingRange struct {
+	StartLine int              // the zero-based line where the range begins (which stays visible when folded)
+	EndLine   int              // the zero-based line where the range ends
+	Kind      FoldingRangeKind // the kind of the range
+}
+
+// FoldingRangeKind is the kind of syntax element that a folding range spans.
+type FoldingRangeKind int
+
+// Folding range kinds
+const (
+	ObjectFoldingRange  FoldingRangeKind = iota // the properties of an object
+	ArrayFoldingRange                           // the elements of an array
+	CommentFoldingRange                         // a multi-line block comment
+	RegionFoldingRange                          // the lines between `// #region` and `// #endregion` comments
+)
+
+// FoldingRangeOptions specifies options for computing folding ranges.
+type FoldingRangeOptions struct {
+	// RangeLimit is the maximum number of folding ranges to return (0 for no limit).
+	// If there are more, the most deeply nested ranges are omitted.
+	RangeLimit int
+}
+
+var regionMarker = regexp.MustCompile(`^//\s*#(?:(region)|endregion)\b`)
+
+// GetFoldingRanges returns the folding ranges of the JSON document, ordered by start
+// line. An array or object spans the lines from its opening bracket up to the line
+// before its closing bracket (so that the closing bracket remains visible), and
+// only ranges that span at least two lines are returned.
+//
+// Source: https://github.com/microsoft/vscode-json-languageservice/blob/main/src/services/jsonFolding.ts
+func GetFoldingRanges(text string, options FoldingRangeOptions) []FoldingRange {
+	chars := []rune(text)
+	lines := lineindex.New(chars)
+	var ranges []FoldingRange
+	var nestingLevels []int // the nesting level of each range
+	var stack []FoldingRange
+	prevStart := -1
+
+	addRange := func(r FoldingRange) {
+		ranges = append(ranges, r)
+		nestingLevels = append(nestingLevels, len(stack))
+	}
+
+	scanner := NewScanner(text, ScanOptions{Trivia: true})
+	for token := scanner.Scan(); token != EOF; token = scanner.Scan() {
+		switch token {
+		case OpenBraceToken, OpenBracketToken:
+			kind := ObjectFoldingRange
+			if token == OpenBracketToken {
+				kind = ArrayFoldingRange
+			}
+			startLine := lines.Line(scanner.TokenOffset())
+			stack = append(stack, FoldingRange{StartLine: startLine, EndLine: startLine, Kind: kind})
+
+		case CloseBraceToken, CloseBracketToken:
+			kind := ObjectFoldingRange
+			if token == CloseBracketToken {
+				kind = ArrayFoldingRange
+			}
+			if len(stack) > 0 && stack[len(stack)-1].Kind == kind {
+				r := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				line := lines.Line(scanner.TokenOffset())
+				if line > r.StartLine+1 && prevStart != r.StartLine {
+					r.EndLine = line - 1
+					addRange(r)
+					prevStart = r.StartLine
+				}
+			}
+
+		case BlockCommentTrivia:
+			startLine := lines.Line(scanner.TokenOffset())
+			endLine := lines.Line(scanner.TokenOffset() + scanner.TokenLength())
+			if scanner.Err() == UnexpectedEndOfComment && startLine+1 < len(lines) {
+				// Continue on the next line, as if the comment was not there.
+				scanner.SetPosition(lines[startLine+1])
+			} else if startLine < endLine {
+				addRange(FoldingRange{StartLine: startLine, EndLine: endLine, Kind: CommentFoldingRange})
+				prevStart = startLine
+			}
+
+		case LineCommentTrivia:
+			comment := string(chars[scanner.TokenOffset() : scanner.TokenOffset()+scanner.TokenLength()])
+			m := regionMarker.FindStringSubmatch(comment)
+			if m == nil {
+				break
+			}
+			line := lines.Line(scanner.TokenOffset())
+			if m[1] != "" { // #region
+				stack = append(stack, FoldingRange{StartLine: line, EndLine: line, Kind: RegionFoldingRange})
+				break
+			}
+			i := len(stack) - 1
+			for i >= 0 && stack[i].Kind != RegionFoldingRange {
+				i--
+			}
+			if i >= 0 {
+				r := stack[i]
+				stack = stack[:i]
+				if line > r.StartLine && prevStart != r.StartLine {
+					r.EndLine = line
+					addRange(r)
+					prevStart = r.StartLine
+				}
+			}
+		}
+	}
+
+	if options.RangeLimit > 0 && len(ranges) > options.RangeLimit {
+		ranges = limitFoldingRanges(ranges, nestingLevels, options.RangeLimit)
+	}
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].StartLine < ranges[j].StartLine })
+	return ranges
+}
+
+// limitFoldingRanges returns the first rangeLimit ranges, preferring ranges with
+// lower nesting levels.
+func limitFoldingRanges(ranges []FoldingRange, nestingLevels []int, rangeLimit int) []FoldingRange {
+	const maxLevel = 30
+	var counts [maxLevel]int
+	for _, level := range nestingLevels {
+		if level < maxLevel {
+			counts[level]++
+		}
+	}
+	entries := 0
+	cutoffLevel := maxLevel
+	for level, n := range counts {
+		if n > 0 {
+			if n+entries > rangeLimit {
+				cutoffLevel = level
+				break
+			}
+			entries += n
+		}
+	}
+
+	var result []FoldingRange
+	for i, r := range ranges {
+		if level := nestingLevels[i]; level < cutoffLevel || level == cutoffLevel && entries < rangeLimit {
+			if level == cutoffLevel {
+				entries++
+			}
+			result = append(result, r)
+		}
+	}
+	return result
+}
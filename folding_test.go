@@ -0,0 +1,109 @@
+// This file was ported from https://github.com/microsoft/vscode-json-languageservice/blob/main/src/test/folding.test.ts,
+// which is licensed as follows:
+//
+// Copyright (c) Microsoft Corporation. All rights reserved. Licensed under the MIT License.
+
+package jsonx
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGetFoldingRanges(t *testing.T) {
+	tests := map[string]struct {
+		input   string
+		options FoldingRangeOptions
+		want    []FoldingRange
+	}{
+		"one line": {
+			input: `{"a": [1, {"b": 2}]}`,
+		},
+		"object": {
+			input: "{\n  \"a\": 1\n}",
+			want:  []FoldingRange{{0, 1, ObjectFoldingRange}},
+		},
+		"too short": {
+			input: "{\n}",
+		},
+		"nested": {
+			input: "{\n  \"a\": [\n    1\n  ],\n  \"b\": {\n    \"c\": {\n    }\n  }\n}",
+			want: []FoldingRange{
+				{0, 7, ObjectFoldingRange},
+				{1, 2, ArrayFoldingRange},
+				{4, 6, ObjectFoldingRange},
+			},
+		},
+		"same start line": {
+			input: "[[\n  1\n],\n[\n  2\n]]",
+			want: []FoldingRange{
+				{0, 1, ArrayFoldingRange},
+				{0, 4, ArrayFoldingRange},
+				{3, 4, ArrayFoldingRange},
+			},
+		},
+		"same start line - consecutive": {
+			input: "[[\n  1\n]]",
+			want:  []FoldingRange{{0, 1, ArrayFoldingRange}},
+		},
+		"mismatched brackets": {
+			input: "[\n  {\n  ]\n}",
+			want:  []FoldingRange{{1, 2, ObjectFoldingRange}},
+		},
+		"comments": {
+			input: "/*\n * a\n */\n[\n  /* b */\n  // c\n  1\n]",
+			want: []FoldingRange{
+				{0, 2, CommentFoldingRange},
+				{3, 6, ArrayFoldingRange},
+			},
+		},
+		"unterminated comment": {
+			input: "[\n  /* a\n  1,\n  2\n]",
+			want:  []FoldingRange{{0, 3, ArrayFoldingRange}},
+		},
+		"regions": {
+			input: "{\n  // #region a\n  \"a\": 1,\n  //#region\n  \"b\": 2\n  // #endregion\n  // #endregion\n  // #regions\n}",
+			want: []FoldingRange{
+				{0, 7, ObjectFoldingRange},
+				{1, 6, RegionFoldingRange},
+				{3, 5, RegionFoldingRange},
+			},
+		},
+		"unmatched endregion": {
+			input: "[\n  // #endregion\n  1\n]",
+			want:  []FoldingRange{{0, 2, ArrayFoldingRange}},
+		},
+		"CRLF": {
+			input: "{\r\n  \"a\": [\r\n    1\r\n  ]\r\n}",
+			want: []FoldingRange{
+				{0, 3, ObjectFoldingRange},
+				{1, 2, ArrayFoldingRange},
+			},
+		},
+		"range limit": {
+			input:   "[\n  [\n    [\n    ]\n  ],\n  [\n    1\n  ]\n]",
+			options: FoldingRangeOptions{RangeLimit: 2},
+			want: []FoldingRange{
+				{0, 7, ArrayFoldingRange},
+				{1, 3, ArrayFoldingRange},
+			},
+		},
+		"range limit - levels": {
+			input:   "[\n  [\n    [\n    ]\n  ],\n  [\n    1\n  ]\n]",
+			options: FoldingRangeOptions{RangeLimit: 3},
+			want: []FoldingRange{
+				{0, 7, ArrayFoldingRange},
+				{1, 3, ArrayFoldingRange},
+				{5, 6, ArrayFoldingRange},
+			},
+		},
+	}
+	for label, test := range tests {
+		t.Run(label, func(t *testing.T) {
+			got := GetFoldingRanges(test.input, test.options)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
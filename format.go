@@ -6,16 +6,128 @@
 package jsonx
 
 import (
+	"sort"
 	"strings"
+	"unicode/utf8"
 )
 
 // FormatOptions specifies formatting options.
 //
 // Source: https://github.com/Microsoft/vscode/blob/c0bc1ace7ca3ce2d6b1aeb2bde9d1bb0f4b4bae6/src/vs/base/common/jsonFormatter.ts#L9
 type FormatOptions struct {
-	TabSize      int    // If indentation is based on spaces (InsertSpaces == true), then what is the number of spaces that make an indent?
+	TabSize      int    // If indentation is based on spaces (InsertSpaces == true), then what is the number of spaces that make an indent? If zero, the indentation is detected from the document (see DetectFormatOptions), including InsertSpaces unless it is true: to indent with tabs regardless of the document, set TabSize too.
 	InsertSpaces bool   // Is indentation based on spaces?
 	EOL          string // The default end of line line character
+
+	Style         FormatStyle // The layout of arrays and objects (PrettyStyle by default)
+	MaxLineWidth  int         // In CompactStyle, the maximum width of a line with an array or object kept on one line (80 if zero)
+	KeepLines     bool        // Keep line breaks between tokens as they are and don't add others (ignored in MinifyStyle)?
+	MaxBlankLines int         // The maximum number of consecutive blank lines to keep where there is a line break (ignored in MinifyStyle)
+	AlignValues   bool        // Align the values of the properties of each object that start a line in a column (ignored in MinifyStyle)?
+	AlignComments bool        // Align the comments after the properties of each object that start a line in a column (ignored in MinifyStyle)?
+
+	KeepBlockCommentIndentation bool                   // Keep the indentation of the lines after the first of block comments (instead of moving them with the first line)?
+	InsertFinalNewline          bool                   // End the document with a line break?
+	RemoveComments              bool                   // Remove all comments?
+	TrailingCommas              TrailingCommaPolicy    // Whether to add or remove trailing commas in arrays and objects
+	SortKeys                    bool                   // Sort the properties of all objects by key?
+	KeyLess                     func(a, b string) bool // If SortKeys, reports whether key a sorts before key b (if nil, keys are sorted by code point)
+}
+
+// FormatStyle is the layout of arrays and objects produced by the formatter.
+type FormatStyle int
+
+// Format styles
+const (
+	PrettyStyle  FormatStyle = iota // each property and array element on its own line
+	CompactStyle                    // like PrettyStyle, but arrays and objects that fit within MaxLineWidth are kept on one line
+	MinifyStyle                     // no whitespace except where needed to separate tokens and terminate line comments
+)
+
+const (
+	defaultTabSize      = 4
+	defaultMaxLineWidth = 80
+)
+
+// TrailingCommaPolicy is how the formatter treats trailing commas in arrays and objects.
+type TrailingCommaPolicy int
+
+// Trailing comma policies
+const (
+	PreserveTrailingCommas TrailingCommaPolicy = iota // keep trailing commas as they are
+	AlwaysTrailingCommas                              // add a trailing comma after the last element of each non-empty array and object whose close token is on its own line
+	NeverTrailingCommas                               // remove all trailing commas
+)
+
+// DetectFormatOptions returns the format options that match the existing style of the
+// JSON document: the indentation (TabSize and InsertSpaces), the end of line sequence
+// (EOL), and whether the document ends with a line break (InsertFinalNewline). The
+// indentation is inferred from the lines that start with a token inside an array or
+// object. If the document has none, it defaults to 4 spaces.
+//
+// The formatting and edit functions detect the indentation and EOL automatically if
+// FormatOptions.TabSize is zero, keeping the options that are set (such as
+// InsertSpaces if it is true). To indent with tabs regardless of the document, set
+// TabSize as well, because InsertSpaces is unset when it is false.
+func DetectFormatOptions(text string) FormatOptions {
+	chars := []rune(text)
+	options := FormatOptions{
+		TabSize:            defaultTabSize,
+		InsertSpaces:       true,
+		EOL:                getEOL(FormatOptions{}, chars),
+		InsertFinalNewline: len(chars) > 0 && (chars[len(chars)-1] == '\n' || chars[len(chars)-1] == '\r'),
+	}
+
+	// Count the lines indented with tabs and with spaces, and for the latter, how
+	// many spaces each nesting level of the line's first token is indented by.
+	tabs, spaces := 0, 0
+	widths := map[int]int{} // indentation width per level -> number of lines
+	scanner := NewScanner(text, ScanOptions{Trivia: true})
+	depth := 0
+	lineStart := false // whether the current token starts a line (after a line break)
+	indent := ""       // the whitespace before the current token, if it starts a line
+	for token := scanner.Scan(); token != EOF; token = scanner.Scan() {
+		switch token {
+		case LineBreakTrivia:
+			lineStart, indent = true, ""
+			continue
+		case Trivia:
+			if lineStart {
+				indent = string(chars[scanner.TokenOffset() : scanner.TokenOffset()+scanner.TokenLength()])
+			}
+			continue
+		case CloseBraceToken, CloseBracketToken:
+			if depth > 0 {
+				depth--
+			}
+		}
+		if lineStart && depth > 0 && indent != "" {
+			if indent[0] == '\t' {
+				tabs++
+			} else if strings.Trim(indent, " ") == "" {
+				spaces++
+				if len(indent)%depth == 0 {
+					widths[len(indent)/depth]++
+				}
+			}
+		}
+		if token == OpenBraceToken || token == OpenBracketToken {
+			depth++
+		}
+		lineStart = false
+	}
+
+	if tabs > spaces {
+		options.InsertSpaces = false
+	} else {
+		count := 0
+		for width, n := range widths {
+			if n > count || n == count && width < options.TabSize {
+				options.TabSize, count = width, n
+			}
+		}
+	}
+	return options
 }
 
 // Format returns edits that format the entire JSON document according to the format
@@ -30,6 +142,24 @@ func Format(text string, options FormatOptions) []Edit {
 //
 // Source: https://github.com/Microsoft/vscode/blob/c0bc1ace7ca3ce2d6b1aeb2bde9d1bb0f4b4bae6/src/vs/base/common/jsonFormatter.ts#L41
 func FormatRange(text string, offset, length int, options FormatOptions) []Edit {
+	return formatRange(text, offset, length, options, false)
+}
+
+// FormatDocument returns the JSON document formatted according to the format options.
+func FormatDocument(text string, options FormatOptions) (string, error) {
+	return ApplyEdits(text, Format(text, options)...)
+}
+
+// IsFormatted reports whether the JSON document is formatted according to the format
+// options (that is, whether Format returns no edits). It stops at the first change.
+func IsFormatted(text string, options FormatOptions) bool {
+	return len(formatRange(text, 0, len([]rune(text)), options, true)) == 0
+}
+
+// formatRange implements FormatRange. If stopAtChange, it stops at the first edit that
+// changes the text, and returns it.
+func formatRange(text string, offset, length int, options FormatOptions, stopAtChange bool) []Edit {
+	options = detectUnsetFormatOptions(text, options)
 	chars := []rune(text)
 
 	rangeStart := offset
@@ -46,6 +176,16 @@ func FormatRange(text string, offset, length int, options FormatOptions) []Edit
 	}
 
 	value := chars[rangeStart:rangeEnd]
+	if options.SortKeys {
+		if sorted, changes, changed := sortKeys(value, options); changed {
+			// Format the document with the sorted properties, and return the
+			// changed objects and the formatting of the rest as edits.
+			sortedText := string(chars[:rangeStart]) + sorted + string(chars[rangeEnd:])
+			options.SortKeys = false
+			edits := FormatRange(sortedText, rangeStart, len([]rune(sorted)), options)
+			return sortedEdits(chars, []rune(sortedText), rangeStart, changes, edits)
+		}
+	}
 	initialIndentLevel := computeIndentLevel(value, 0, options)
 
 	eol := getEOL(options, chars)
@@ -57,30 +197,60 @@ func FormatRange(text string, offset, length int, options FormatOptions) []Edit
 	} else {
 		indentValue = "\t"
 	}
+	if options.Style == MinifyStyle {
+		initialIndentLevel = 0
+		indentValue = ""
+	}
 
 	scanner := NewScanner(string(value), ScanOptions{Trivia: true})
 	formatter := formatter{
 		input:              chars,
 		scanner:            scanner,
+		options:            options,
 		eol:                eol,
 		indentLevel:        0,
 		indentValue:        indentValue,
 		initialIndentLevel: initialIndentLevel,
 		lineBreak:          lineBreak,
 	}
-	return formatter.format(rangeStart, rangeEnd)
+	aligned := (options.AlignValues || options.AlignComments) && options.Style != MinifyStyle
+	formatter.stopAtChange = stopAtChange && !aligned // the alignment can change any edit
+	formatter.prepare(value)
+	edits := formatter.format(rangeStart, rangeEnd)
+	if aligned {
+		edits = align(chars, edits, rangeStart, rangeEnd, options)
+	}
+
+	// Only return the edits that change the text.
+	changes := edits[:0]
+	for _, edit := range edits {
+		if string(chars[edit.Offset:edit.Offset+edit.Length]) != edit.Content {
+			changes = append(changes, edit)
+		}
+	}
+	return changes
 }
 
 type formatter struct {
 	input              []rune
 	scanner            *Scanner
+	options            FormatOptions
 	eol                string
 	indentLevel        int
 	indentValue        string
 	initialIndentLevel int
 	lineBreak          bool
+	blankLines         int // the number of blank lines before the last-scanned token
+
+	removeCommas map[int]bool // offsets of the trailing commas to remove
+	addCommas    map[int]bool // offsets of the tokens to add a trailing comma after (unless on one line with the close token)
+	inlineWidths map[int]int  // in CompactStyle, the width of each array and object (by offset) on one line, or -1 if it can't be on one line
+	inline       []bool       // for each enclosing array and object, whether it is kept on one line
+	column       int          // the column after the last token
 
-	edits []Edit
+	edits        []Edit // the replacements of the text between tokens, including those that don't change it
+	stopAtChange bool   // whether to stop at the first edit that changes the text
+	changed      bool   // whether an edit changes the text
 }
 
 func (f *formatter) format(rangeStart, rangeEnd int) (editOperations []Edit) {
@@ -89,19 +259,48 @@ func (f *formatter) format(rangeStart, rangeEnd int) (editOperations []Edit) {
 		firstTokenStart := f.scanner.TokenOffset() + rangeStart
 		initialIndent := strings.Repeat(f.indentValue, f.initialIndentLevel)
 		f.addEdit(initialIndent, rangeStart, firstTokenStart)
+		f.advanceColumn(initialIndent)
+		f.emitToken(firstToken, rangeStart)
 	}
 
-	for firstToken != EOF {
+	prevToken := EOF             // the last token kept before firstToken
+	lineBreakAfterComma := false // whether a line break must follow the removed comma that is firstToken
+	for firstToken != EOF && !f.changed {
+		firstTokenStart := f.scanner.TokenOffset() + rangeStart
 		firstTokenEnd := f.scanner.TokenOffset() + f.scanner.TokenLength() + rangeStart
+		firstTokenErr := f.scanner.Err()
+		firstTokenColumn := f.column // the column after firstToken
+		if firstToken == CommaToken && f.removeCommas[f.scanner.TokenOffset()] {
+			f.addEdit("", firstTokenStart, firstTokenEnd)
+		} else {
+			prevToken = firstToken
+		}
+		comma := "" // the trailing comma to add after firstToken
+		if f.addCommas[f.scanner.TokenOffset()] && !(len(f.inline) > 0 && f.inline[len(f.inline)-1]) {
+			comma = ","
+			prevToken = CommaToken
+		}
 		secondToken := f.scanNext()
 
 		replaceContent := ""
+		needsLineBreak := false
 		for !f.lineBreak && (secondToken == LineCommentTrivia || secondToken == BlockCommentTrivia) {
 			// comments on the same line: keep them on the same line, but ignore them otherwise
 			commentTokenStart := f.scanner.TokenOffset() + rangeStart
-			f.addEdit(" ", firstTokenEnd, commentTokenStart)
+			separator := f.space()
+			if separator == "" && isWordToken(prevToken) {
+				// keep the comment from merging with the preceding word when rescanned
+				separator = " "
+			}
+			f.addEdit(comma+separator, firstTokenEnd, commentTokenStart)
+			f.advanceColumn(comma + separator)
+			f.emitToken(secondToken, rangeStart)
+			comma = ""
+			prevToken = secondToken
+			firstTokenErr = f.scanner.Err()
 			firstTokenEnd = f.scanner.TokenOffset() + f.scanner.TokenLength() + rangeStart
-			if secondToken == LineCommentTrivia {
+			needsLineBreak = secondToken == LineCommentTrivia
+			if needsLineBreak {
 				replaceContent = f.newLineAndIndent()
 			} else {
 				replaceContent = ""
@@ -111,69 +310,418 @@ func (f *formatter) format(rangeStart, rangeEnd int) (editOperations []Edit) {
 
 		if secondToken == CloseBraceToken {
 			if firstToken != OpenBraceToken {
-				f.indentLevel--
-				replaceContent = f.newLineAndIndent()
+				replaceContent = f.endBlock()
 			}
 		} else if secondToken == CloseBracketToken {
 			if firstToken != OpenBracketToken {
-				f.indentLevel--
-				replaceContent = f.newLineAndIndent()
+				replaceContent = f.endBlock()
 			}
 		} else if secondToken != EOF {
 			switch firstToken {
 			case OpenBracketToken, OpenBraceToken:
-				f.indentLevel++
-				replaceContent = f.newLineAndIndent()
-			case CommaToken, LineCommentTrivia:
+				f.beginBlock(firstTokenStart-rangeStart, firstTokenColumn)
+				replaceContent = f.lineBreakOr("")
+			case CommaToken:
+				replaceContent = f.lineBreakOr(" ")
+			case LineCommentTrivia:
 				replaceContent = f.newLineAndIndent()
-				break
 			case BlockCommentTrivia:
 				if f.lineBreak {
-					replaceContent = f.newLineAndIndent()
+					replaceContent = f.lineBreakOr(" ")
 				} else {
 					// symbol following comment on the same line: keep on same line, separate with ' '
-					replaceContent = " "
+					replaceContent = f.space()
 				}
 			case ColonToken:
-				replaceContent = " "
-			case NullKeyword, TrueKeyword, FalseKeyword, NumericLiteral:
-				if secondToken == NullKeyword || secondToken == FalseKeyword || secondToken == NumericLiteral {
-					replaceContent = " "
-				}
+				replaceContent = f.space()
 			}
 			if f.lineBreak && (secondToken == LineCommentTrivia || secondToken == BlockCommentTrivia) {
+				replaceContent = f.lineBreakOr(" ")
+			}
+			if replaceContent == "" && f.options.Style == CompactStyle && (prevToken == BlockCommentTrivia || secondToken == BlockCommentTrivia) {
+				// separate block comments the same way whether or not they were on the same line
+				replaceContent = " "
+			}
+			if replaceContent == "" && isWordToken(prevToken) && (isWordToken(secondToken) || secondToken == LineCommentTrivia || secondToken == BlockCommentTrivia) {
+				// keep the tokens separated so they don't merge when rescanned
+				replaceContent = " "
+			}
+		}
+		if f.keepLines() && f.lineBreak && secondToken != EOF && !strings.HasPrefix(replaceContent, f.eol) {
+			replaceContent = f.newLineAndIndent()
+		}
+		if secondToken == EOF {
+			if f.options.InsertFinalNewline && rangeEnd == len(f.input) && firstTokenErr != UnexpectedEndOfComment && firstTokenErr != UnexpectedEndOfString {
+				replaceContent = f.eol
+			} else if firstToken == LineCommentTrivia && f.options.Style == MinifyStyle {
+				// like a line comment on the same line, end it with a line break
+				replaceContent = f.eol
+			}
+		} else {
+			if firstToken == LineCommentTrivia || (firstToken == StringLiteral && firstTokenErr == UnexpectedEndOfString) {
+				// the line break terminates the line comment or unterminated string
+				needsLineBreak = true
+			}
+			if lineBreakAfterComma {
+				needsLineBreak = true
+				lineBreakAfterComma = false
+			}
+			if secondToken == CommaToken && f.removeCommas[f.scanner.TokenOffset()] {
+				// the whitespace follows the removed comma instead
+				lineBreakAfterComma = needsLineBreak
+				replaceContent = ""
+			} else if needsLineBreak && !strings.HasPrefix(replaceContent, f.eol) {
 				replaceContent = f.newLineAndIndent()
 			}
-
 		}
 		secondTokenStart := f.scanner.TokenOffset() + rangeStart
-		f.addEdit(replaceContent, firstTokenEnd, secondTokenStart)
+		f.addEdit(comma+replaceContent, firstTokenEnd, secondTokenStart)
+		f.advanceColumn(comma + replaceContent)
+		f.emitToken(secondToken, rangeStart)
 		firstToken = secondToken
 	}
 	return f.edits
 }
 
+// prepare scans the value to be formatted to find the trailing commas to add or
+// remove and, in CompactStyle, the arrays and objects that can be kept on one line.
+func (f *formatter) prepare(value []rune) {
+	compact := f.options.Style == CompactStyle
+	if !compact && f.options.TrailingCommas == PreserveTrailingCommas {
+		return
+	}
+
+	type token struct {
+		kind           SyntaxKind
+		offset, length int
+		forcesBreak    bool // whether a line break must follow the token
+		lineBreak      bool // whether a line break precedes the token
+	}
+	var tokens []token
+	lineBreak := false
+	scanner := NewScanner(string(value), ScanOptions{Trivia: true})
+	for kind := scanner.Scan(); kind != EOF; kind = scanner.Scan() {
+		tok := token{kind: kind, offset: scanner.TokenOffset(), length: scanner.TokenLength(), lineBreak: lineBreak}
+		switch kind {
+		case Trivia:
+			continue
+		case LineBreakTrivia:
+			lineBreak = true
+			continue
+		case LineCommentTrivia:
+			tok.forcesBreak = true
+		case BlockCommentTrivia:
+			tok.forcesBreak = strings.ContainsAny(string(value[tok.offset:tok.offset+tok.length]), "\r\n")
+		case StringLiteral:
+			tok.forcesBreak = scanner.Err() == UnexpectedEndOfString
+		}
+		if f.options.RemoveComments && (kind == LineCommentTrivia || kind == BlockCommentTrivia) {
+			continue
+		}
+		tokens = append(tokens, tok)
+		lineBreak = false
+	}
+
+	if f.options.TrailingCommas != PreserveTrailingCommas {
+		f.removeCommas = map[int]bool{}
+		f.addCommas = map[int]bool{}
+		depth := 0
+		lastValue := -1 // the index of the last token (ignoring comments) if it ends a value
+		for i, tok := range tokens {
+			switch tok.kind {
+			case LineCommentTrivia, BlockCommentTrivia:
+				continue
+			case OpenBraceToken, OpenBracketToken:
+				depth++
+			case CloseBraceToken, CloseBracketToken:
+				if depth > 0 && lastValue >= 0 && !tokens[lastValue].forcesBreak && f.options.TrailingCommas == AlwaysTrailingCommas && f.closeOnOwnLine(tok.lineBreak || tokens[i-1].kind == LineCommentTrivia) {
+					f.addCommas[tokens[lastValue].offset] = true
+				}
+				depth--
+			case CommaToken:
+				if depth > 0 && lastValue >= 0 && !tokens[lastValue].forcesBreak && f.options.TrailingCommas == NeverTrailingCommas {
+					for _, next := range tokens[i+1:] {
+						if next.kind == CloseBraceToken || next.kind == CloseBracketToken {
+							f.removeCommas[tok.offset] = true
+						} else if next.kind == LineCommentTrivia || next.kind == BlockCommentTrivia {
+							continue
+						}
+						break
+					}
+				}
+			}
+			if tok.kind == OpenBraceToken || tok.kind == OpenBracketToken || tok.kind == CommaToken || tok.kind == ColonToken {
+				lastValue = -1
+			} else {
+				lastValue = i
+			}
+		}
+	}
+
+	if compact {
+		// Compute the position of each token as if the value was formatted on
+		// a single line. The width of an array or object on one line is the
+		// distance between its open and close tokens.
+		f.inlineWidths = map[int]int{}
+		type block struct {
+			kind                SyntaxKind
+			offset, pos, breaks int
+		}
+		var blocks []block
+		pos, breaks := 0, 0
+		for i, tok := range tokens {
+			if i > 0 {
+				prev := tokens[i-1]
+				switch {
+				case prev.kind == BlockCommentTrivia, tok.kind == BlockCommentTrivia:
+					pos++
+				case tok.kind == CloseBraceToken || tok.kind == CloseBracketToken:
+				case prev.kind == OpenBraceToken || prev.kind == OpenBracketToken:
+				case prev.kind == CommaToken, prev.kind == ColonToken,
+					isWordToken(prev.kind) && isWordToken(tok.kind):
+					pos++
+				}
+			}
+			if tok.kind == OpenBraceToken || tok.kind == OpenBracketToken {
+				blocks = append(blocks, block{kind: tok.kind, offset: tok.offset, pos: pos, breaks: breaks})
+			}
+			if !f.removeCommas[tok.offset] || tok.kind != CommaToken {
+				pos += tok.length
+			}
+			if tok.forcesBreak {
+				breaks++
+			}
+			if (tok.kind == CloseBraceToken || tok.kind == CloseBracketToken) && len(blocks) > 0 {
+				b := blocks[len(blocks)-1]
+				blocks = blocks[:len(blocks)-1]
+				matched := (b.kind == OpenBraceToken) == (tok.kind == CloseBraceToken)
+				if matched && breaks == b.breaks {
+					f.inlineWidths[b.offset] = pos - b.pos
+				} else {
+					f.inlineWidths[b.offset] = -1
+				}
+			}
+		}
+	}
+}
+
+// beginBlock is called when a non-empty array or object begins at the offset
+// (relative to the formatted range), with its open token ending at the column.
+func (f *formatter) beginBlock(offset, column int) {
+	f.indentLevel++
+	inline := len(f.inline) > 0 && f.inline[len(f.inline)-1]
+	if !inline && f.options.Style == CompactStyle {
+		maxLineWidth := f.options.MaxLineWidth
+		if maxLineWidth == 0 {
+			maxLineWidth = defaultMaxLineWidth
+		}
+		width, ok := f.inlineWidths[offset]
+		inline = ok && width >= 0 && column-1+width <= maxLineWidth
+	}
+	f.inline = append(f.inline, inline)
+}
+
+// endBlock is called when a non-empty array or object ends. It returns the
+// whitespace to precede its close token.
+func (f *formatter) endBlock() string {
+	replaceContent := f.lineBreakOr("")
+	f.indentLevel--
+	if len(f.inline) > 0 {
+		f.inline = f.inline[:len(f.inline)-1]
+	}
+	if replaceContent != "" {
+		// the close token is indented at the level of the enclosing block
+		replaceContent = f.newLineAndIndent()
+	}
+	return replaceContent
+}
+
+// lineBreakOr returns the whitespace to separate two tokens at a point where the
+// style would break the line, which is the inlineSeparator when the enclosing
+// array or object is kept on one line.
+func (f *formatter) lineBreakOr(inlineSeparator string) string {
+	if f.options.Style == MinifyStyle {
+		return ""
+	}
+	if f.keepLines() {
+		if f.lineBreak {
+			return f.newLineAndIndent()
+		}
+		return inlineSeparator
+	}
+	if len(f.inline) > 0 && f.inline[len(f.inline)-1] {
+		return inlineSeparator
+	}
+	return f.newLineAndIndent()
+}
+
+// closeOnOwnLine reports whether the close token of a non-empty array or object
+// is put on its own line, given whether a line break precedes it in the text. (In
+// CompactStyle, it is not if the array or object is kept on one line, which is
+// only known while formatting.)
+func (f *formatter) closeOnOwnLine(lineBreak bool) bool {
+	if f.keepLines() {
+		return lineBreak
+	}
+	return f.options.Style != MinifyStyle
+}
+
+// keepLines reports whether line breaks are kept as they are.
+func (f *formatter) keepLines() bool {
+	return f.options.KeepLines && f.options.Style != MinifyStyle
+}
+
+// space returns the whitespace to separate two tokens on the same line.
+func (f *formatter) space() string {
+	if f.options.Style == MinifyStyle {
+		return ""
+	}
+	return " "
+}
+
+// advanceColumn updates the column after the text was emitted.
+func (f *formatter) advanceColumn(text string) {
+	if i := strings.LastIndexAny(text, "\r\n"); i >= 0 {
+		f.column = 0
+		text = text[i+1:]
+	}
+	for _, ch := range text {
+		f.column += f.width(ch)
+	}
+}
+
+// emitToken is called when the last-scanned token is emitted after the text
+// that precedes it, and updates the column after it.
+func (f *formatter) emitToken(token SyntaxKind, rangeStart int) {
+	if token == EOF || (token == CommaToken && f.removeCommas[f.scanner.TokenOffset()]) {
+		return
+	}
+	start := f.scanner.TokenOffset() + rangeStart
+	text := f.input[start : start+f.scanner.TokenLength()]
+	if token == BlockCommentTrivia && !f.options.KeepBlockCommentIndentation {
+		text = f.reindentComment(start, text)
+	}
+	f.advanceColumn(string(text))
+}
+
+// reindentComment moves the lines after the first of the block comment (starting
+// at the offset) by the number of columns its first line is moved, and returns the
+// reindented comment.
+func (f *formatter) reindentComment(offset int, comment []rune) []rune {
+	oldColumn := 0
+	lineStart := offset
+	for lineStart > 0 && f.input[lineStart-1] != '\n' && f.input[lineStart-1] != '\r' {
+		lineStart--
+	}
+	for _, ch := range f.input[lineStart:offset] {
+		oldColumn += f.width(ch)
+	}
+	shift := f.column - oldColumn
+	if shift == 0 {
+		return comment
+	}
+
+	var reindented []rune
+	for i := 0; i < len(comment); {
+		// Copy the rest of the line, including the line break.
+		lineEnd := i
+		for lineEnd < len(comment) && comment[lineEnd] != '\n' && comment[lineEnd] != '\r' {
+			lineEnd++
+		}
+		if lineEnd < len(comment) && comment[lineEnd] == '\r' {
+			lineEnd++
+		}
+		if lineEnd < len(comment) && comment[lineEnd] == '\n' {
+			lineEnd++
+		}
+		reindented = append(reindented, comment[i:lineEnd]...)
+		if i = lineEnd; i == len(comment) {
+			break
+		}
+
+		// Move the next line, unless it is blank.
+		width := 0
+		for lineEnd < len(comment) && (comment[lineEnd] == ' ' || comment[lineEnd] == '\t') {
+			width += f.width(comment[lineEnd])
+			lineEnd++
+		}
+		if lineEnd == len(comment) || comment[lineEnd] == '\n' || comment[lineEnd] == '\r' {
+			continue
+		}
+		if width += shift; width < 0 {
+			width = 0
+		}
+		indent := f.indentation(width)
+		f.addEdit(indent, offset+i, offset+lineEnd)
+		reindented = append(reindented, []rune(indent)...)
+		i = lineEnd
+	}
+	return reindented
+}
+
+// indentation returns the whitespace that indents a line by the width.
+func (f *formatter) indentation(width int) string {
+	if f.options.InsertSpaces {
+		return strings.Repeat(" ", width)
+	}
+	return strings.Repeat("\t", width/f.tabSize()) + strings.Repeat(" ", width%f.tabSize())
+}
+
+// width returns the number of columns taken by the character.
+func (f *formatter) width(ch rune) int {
+	if ch == '\t' {
+		return f.tabSize()
+	}
+	return 1
+}
+
+func (f *formatter) tabSize() int {
+	if f.options.TabSize == 0 {
+		return defaultTabSize
+	}
+	return f.options.TabSize
+}
+
 func (f *formatter) newLineAndIndent() string {
 	n := f.initialIndentLevel + f.indentLevel
 	if n < 0 {
 		n = 0
 	}
-	return f.eol + strings.Repeat(f.indentValue, n)
+	blankLines := 0
+	if f.options.Style != MinifyStyle && f.options.MaxBlankLines > 0 {
+		blankLines = f.blankLines
+		if blankLines > f.options.MaxBlankLines {
+			blankLines = f.options.MaxBlankLines
+		}
+	}
+	return strings.Repeat(f.eol, 1+blankLines) + strings.Repeat(f.indentValue, n)
 }
 
 func (f *formatter) scanNext() SyntaxKind {
 	token := f.scanner.Scan()
 	f.lineBreak = false
-	for token == Trivia || token == LineBreakTrivia {
-		f.lineBreak = f.lineBreak || (token == LineBreakTrivia)
+	f.blankLines = 0
+	lineBreaks := 0 // the number of consecutive line breaks
+	for token == Trivia || token == LineBreakTrivia || (f.options.RemoveComments && (token == LineCommentTrivia || token == BlockCommentTrivia)) {
+		switch token {
+		case LineBreakTrivia:
+			f.lineBreak = true
+			lineBreaks++
+			if lineBreaks-1 > f.blankLines {
+				f.blankLines = lineBreaks - 1
+			}
+		case LineCommentTrivia, BlockCommentTrivia:
+			lineBreaks = 0 // a line with a removed comment isn't blank
+		}
 		token = f.scanner.Scan()
 	}
 	return token
 }
 
 func (f *formatter) addEdit(text string, startOffset, endOffset int) {
-	if string(f.input[startOffset:endOffset]) != text {
-		f.edits = append(f.edits, Edit{Offset: startOffset, Length: endOffset - startOffset, Content: text})
+	f.edits = append(f.edits, Edit{Offset: startOffset, Length: endOffset - startOffset, Content: text})
+	if f.stopAtChange && !f.changed {
+		f.changed = string(f.input[startOffset:endOffset]) != text
 	}
 }
 
@@ -182,7 +730,7 @@ func computeIndentLevel(chars []rune, offset int, options FormatOptions) int {
 	nChars := 0
 	tabSize := options.TabSize
 	if tabSize == 0 {
-		tabSize = 4
+		tabSize = defaultTabSize
 	}
 	for i < len(chars) {
 		ch := chars[i]
@@ -198,15 +746,33 @@ func computeIndentLevel(chars []rune, offset int, options FormatOptions) int {
 	return nChars / tabSize
 }
 
+// detectUnsetFormatOptions returns the format options, with the indentation detected
+// from the JSON document if TabSize is zero. It only detects the settings that may
+// be unset: the indentation width, whether to indent with spaces if InsertSpaces is
+// false (which can't be told apart from an explicit false), and the EOL if it is
+// empty.
+func detectUnsetFormatOptions(text string, options FormatOptions) FormatOptions {
+	if options.TabSize != 0 {
+		return options
+	}
+	detected := DetectFormatOptions(text)
+	options.TabSize = detected.TabSize
+	if !options.InsertSpaces {
+		options.InsertSpaces = detected.InsertSpaces
+	}
+	if options.EOL == "" {
+		options.EOL = detected.EOL
+	}
+	return options
+}
+
 func getEOL(options FormatOptions, chars []rune) string {
-	for i, ch := range chars {
-		if ch == '\r' {
-			if i+1 < len(chars) && chars[i+1] == '\n' {
-				return "\r\n"
-			}
-			return "\r"
-		} else if ch == '\n' {
-			return "\n"
+	// Only consider line breaks between tokens, not those in (invalid) strings
+	// or comments, which are kept as is.
+	scanner := NewScanner(string(chars), ScanOptions{Trivia: true})
+	for token := scanner.Scan(); token != EOF; token = scanner.Scan() {
+		if token == LineBreakTrivia && strings.ContainsAny(scanner.Value(), "\r\n") {
+			return scanner.Value()
 		}
 	}
 	if options.EOL != "" {
@@ -215,6 +781,525 @@ func getEOL(options FormatOptions, chars []rune) string {
 	return "\n"
 }
 
+// isWordToken reports whether the token kind would merge with an adjacent
+// word token if the whitespace between them was removed.
+func isWordToken(kind SyntaxKind) bool {
+	switch kind {
+	case NullKeyword, TrueKeyword, FalseKeyword, NumericLiteral, Unknown:
+		return true
+	}
+	return false
+}
+
 func isEOL(chars []rune, offset int) bool {
 	return chars[offset] == '\r' || chars[offset] == '\n'
 }
+
+// sortKeys returns the value with the properties of all objects sorted by key, the
+// ranges of the value that it changed, and whether any properties were reordered.
+// Comments before a property (and those after it on the same line) are moved with
+// the property, unless comments are removed. Objects with syntax errors are left
+// unsorted.
+func sortKeys(value []rune, options FormatOptions) (sorted string, changes []sortChange, changed bool) {
+	s := keySorter{less: options.KeyLess}
+	if s.less == nil {
+		s.less = func(a, b string) bool { return a < b }
+	}
+	scanner := NewScanner(string(value), ScanOptions{Trivia: true})
+	for kind := scanner.Scan(); kind != EOF; kind = scanner.Scan() {
+		offset, length := scanner.TokenOffset(), scanner.TokenLength()
+		p := sortPiece{kind: kind, text: string(value[offset : offset+length]), offset: offset, invalid: scanner.Err() != None}
+		if options.RemoveComments && (kind == LineCommentTrivia || kind == BlockCommentTrivia) {
+			// Keep the tokens around the comment separated.
+			p = sortPiece{kind: Trivia, text: " ", offset: offset, changes: []sortChange{{offset: offset, length: length, sortedLength: 1}}}
+		}
+		if kind == StringLiteral {
+			p.key = scanner.Value()
+		}
+		s.tokens = append(s.tokens, p)
+	}
+
+	var b strings.Builder
+	n := 0 // the length of the sorted value so far
+	for i := 0; i < len(s.tokens); {
+		var p sortPiece
+		p, i = s.piece(i)
+		b.WriteString(p.text)
+		changes = p.appendChanges(changes, n)
+		n += utf8.RuneCountInString(p.text)
+	}
+	return b.String(), changes, s.changed
+}
+
+// A sortChange is a range of the value that sortKeys changed: the text between the
+// braces of an object with reordered properties, or a removed comment.
+type sortChange struct {
+	offset, length             int // the range in the value
+	sortedOffset, sortedLength int // the range in the sorted value
+}
+
+type keySorter struct {
+	tokens  []sortPiece // all tokens, including trivia
+	less    func(a, b string) bool
+	changed bool
+}
+
+// sortPiece is a token, or an entire array or object.
+type sortPiece struct {
+	kind    SyntaxKind // the kind of the token (or of the open token of the array or object)
+	text    string
+	key     string       // the value of a string literal
+	offset  int          // the offset of the piece in the value
+	invalid bool         // whether the piece has a scan error
+	changes []sortChange // the changes in the piece, with the sorted offsets relative to the start of the piece
+}
+
+// appendChanges appends the changes in the piece, which starts at the offset in the
+// sorted value, to the changes.
+func (p sortPiece) appendChanges(changes []sortChange, offset int) []sortChange {
+	for _, c := range p.changes {
+		c.sortedOffset += offset
+		changes = append(changes, c)
+	}
+	return changes
+}
+
+func (p sortPiece) isTrivia() bool {
+	switch p.kind {
+	case Trivia, LineBreakTrivia, LineCommentTrivia, BlockCommentTrivia:
+		return true
+	}
+	return false
+}
+
+// piece returns the piece starting at the token index, and the index after it.
+func (s *keySorter) piece(i int) (sortPiece, int) {
+	open := s.tokens[i]
+	if open.kind != OpenBraceToken && open.kind != OpenBracketToken {
+		return open, i + 1
+	}
+
+	// Collect the pieces up to the close token.
+	var pieces []sortPiece
+	i++
+	for i < len(s.tokens) && s.tokens[i].kind != CloseBraceToken && s.tokens[i].kind != CloseBracketToken {
+		var p sortPiece
+		p, i = s.piece(i)
+		pieces = append(pieces, p)
+	}
+	reordered := false
+	if open.kind == OpenBraceToken && i < len(s.tokens) && s.tokens[i].kind == CloseBraceToken {
+		pieces, reordered = s.sortProperties(pieces)
+	}
+
+	piece := sortPiece{kind: open.kind, offset: open.offset}
+	var b strings.Builder
+	b.WriteString(open.text)
+	n := utf8.RuneCountInString(open.text) // the length of the piece so far
+	for _, p := range pieces {
+		b.WriteString(p.text)
+		piece.invalid = piece.invalid || p.invalid
+		if !reordered {
+			piece.changes = p.appendChanges(piece.changes, n)
+		}
+		n += utf8.RuneCountInString(p.text)
+	}
+	if reordered {
+		// The change includes the changes in the properties.
+		start, sortedStart := open.offset+len([]rune(open.text)), utf8.RuneCountInString(open.text)
+		piece.changes = []sortChange{{offset: start, length: s.tokens[i].offset - start, sortedOffset: sortedStart, sortedLength: n - sortedStart}}
+	}
+	if i < len(s.tokens) {
+		b.WriteString(s.tokens[i].text)
+		i++
+	}
+	piece.text = b.String()
+	return piece, i
+}
+
+// sortProperties returns the pieces between the braces of an object with the
+// properties sorted by key, and whether any properties were reordered.
+func (s *keySorter) sortProperties(pieces []sortPiece) ([]sortPiece, bool) {
+	// Split the pieces into the segments separated by commas.
+	var segments [][]sortPiece
+	start := 0
+	for i, p := range pieces {
+		if p.invalid {
+			return pieces, false // moving the piece could change how the text is scanned
+		}
+		if p.kind == CommaToken {
+			segments = append(segments, pieces[start:i])
+			start = i + 1
+		}
+	}
+	segments = append(segments, pieces[start:])
+
+	// sameLine returns the number of pieces at the start of the segment that are
+	// trivia on the same line, if the line ends there.
+	sameLine := func(segment []sortPiece) int {
+		for i, p := range segment {
+			if p.kind == LineBreakTrivia {
+				return i
+			}
+			if !p.isTrivia() {
+				return 0
+			}
+		}
+		return len(segment)
+	}
+
+	type property struct {
+		key    string
+		body   []sortPiece // the property, preceded by its leading comments
+		trivia []sortPiece // the trivia after the property on the same line
+		index  int         // the index of the property in the object
+	}
+	var properties []property
+	head := segments[0][:sameLine(segments[0])] // the trivia after the open brace on the same line
+	var tail []sortPiece                        // the trivia before the close brace
+	trailingComma := false
+	rest := segments[0][len(head):]
+	for i := range segments {
+		last := len(rest) - 1
+		for last >= 0 && rest[last].isTrivia() {
+			last--
+		}
+		if last < 0 {
+			if i == 0 || i < len(segments)-1 {
+				return pieces, false // an empty object or a missing property
+			}
+			trailingComma = true
+			tail = rest
+			break
+		}
+		p := property{body: rest[:last+1], trivia: rest[last+1:], index: i}
+		for _, q := range p.body {
+			if !q.isTrivia() {
+				if q.kind != StringLiteral {
+					return pieces, false // not a property
+				}
+				p.key = q.key
+				break
+			}
+		}
+		if i < len(segments)-1 {
+			next := segments[i+1]
+			n := sameLine(next)
+			p.trivia = append(p.trivia[:len(p.trivia):len(p.trivia)], next[:n]...)
+			rest = next[n:]
+		} else {
+			n := sameLine(p.trivia)
+			p.trivia, tail = p.trivia[:n], p.trivia[n:]
+		}
+		properties = append(properties, p)
+	}
+
+	sort.SliceStable(properties, func(i, j int) bool { return s.less(properties[i].key, properties[j].key) })
+	sorted := true
+	for i, p := range properties {
+		if p.index != i {
+			sorted = false
+		}
+	}
+	if sorted {
+		return pieces, false
+	}
+	s.changed = true
+
+	var sortedPieces []sortPiece
+	needsLineBreak := false // whether the last piece is a line comment
+	add := func(pieces ...sortPiece) {
+		for _, p := range pieces {
+			if needsLineBreak && p.kind != LineBreakTrivia {
+				sortedPieces = append(sortedPieces, sortPiece{kind: LineBreakTrivia, text: "\n"})
+			}
+			sortedPieces = append(sortedPieces, p)
+			needsLineBreak = p.kind == LineCommentTrivia
+		}
+	}
+	add(head...)
+	for i, p := range properties {
+		add(p.body...)
+		if i < len(properties)-1 || trailingComma {
+			add(sortPiece{kind: CommaToken, text: ","})
+		}
+		add(p.trivia...)
+	}
+	add(tail...)
+	if needsLineBreak {
+		sortedPieces = append(sortedPieces, sortPiece{kind: LineBreakTrivia, text: "\n"})
+	}
+	return sortedPieces, true
+}
+
+// sortedEdits returns edits that change the text to the formatted text with sorted
+// properties, given the changes that sortKeys made to the value at the offset and
+// the edits that format the sorted text. Each change (joined with the formatting
+// edits and other changes that touch it) is returned as one edit, without the
+// lines at its start and end that are unchanged. The formatting edits of the rest
+// of the text are returned as is.
+func sortedEdits(chars, sortedChars []rune, offset int, changes []sortChange, formatEdits []Edit) []Edit {
+	sort.Slice(formatEdits, func(i, j int) bool { return formatEdits[i].Offset < formatEdits[j].Offset })
+	var edits []Edit
+	delta := 0 // the offset in the sorted text minus the offset in the text, after the last change
+	j := 0     // the index of the next formatting edit
+	for i := 0; i < len(changes); {
+		c := changes[i]
+		i++
+		start, end := offset+c.offset, offset+c.offset+c.length
+		sortedStart, sortedEnd := offset+c.sortedOffset, offset+c.sortedOffset+c.sortedLength
+		for ; j < len(formatEdits) && formatEdits[j].Offset+formatEdits[j].Length < sortedStart; j++ {
+			edit := formatEdits[j]
+			edit.Offset -= delta
+			edits = append(edits, edit)
+		}
+
+		var joined []Edit // the formatting edits in the change
+		for {
+			if j < len(formatEdits) && formatEdits[j].Offset <= sortedEnd {
+				edit := formatEdits[j]
+				j++
+				if edit.Offset < sortedStart {
+					start -= sortedStart - edit.Offset
+					sortedStart = edit.Offset
+				}
+				if editEnd := edit.Offset + edit.Length; editEnd > sortedEnd {
+					end += editEnd - sortedEnd
+					sortedEnd = editEnd
+				}
+				joined = append(joined, edit)
+			} else if i < len(changes) && offset+changes[i].sortedOffset <= sortedEnd {
+				c := changes[i]
+				i++
+				cEnd := offset + c.sortedOffset + c.sortedLength
+				if cEnd > sortedEnd {
+					sortedEnd = cEnd
+				}
+				end = offset + c.offset + c.length + sortedEnd - cEnd
+			} else {
+				break
+			}
+		}
+		delta = sortedEnd - end
+
+		var b strings.Builder
+		pos := sortedStart
+		for _, edit := range joined {
+			b.WriteString(string(sortedChars[pos:edit.Offset]))
+			b.WriteString(edit.Content)
+			pos = edit.Offset + edit.Length
+		}
+		b.WriteString(string(sortedChars[pos:sortedEnd]))
+		old, content := chars[start:end], []rune(b.String())
+		if string(old) == string(content) {
+			continue
+		}
+
+		prefix := 0 // the length of the unchanged lines at the start
+		for k := 0; k < len(old) && k < len(content) && old[k] == content[k]; k++ {
+			if old[k] == '\n' {
+				prefix = k + 1
+			}
+		}
+		suffix := 0 // the length of the unchanged lines at the end
+		for k := 1; k <= len(old)-prefix && k <= len(content)-prefix && old[len(old)-k] == content[len(content)-k]; k++ {
+			if old[len(old)-k] == '\n' {
+				suffix = k - 1
+			}
+		}
+		edits = append(edits, Edit{Offset: start + prefix, Length: len(old) - prefix - suffix, Content: string(content[prefix : len(content)-suffix])})
+	}
+	for ; j < len(formatEdits); j++ {
+		edit := formatEdits[j]
+		edit.Offset -= delta
+		edits = append(edits, edit)
+	}
+	return edits
+}
+
+// align returns the edits (which replace the text between all tokens in the
+// formatted range) changed to align the values and comments of the properties of
+// the objects that overlap the formatted range. The alignment of the properties
+// outside the formatted range is fixed with additional edits.
+func align(chars []rune, edits []Edit, rangeStart, rangeEnd int, options FormatOptions) []Edit {
+	formattedText, err := ApplyEdits(string(chars), edits...)
+	if err != nil {
+		return edits // should never happen, but the edits still format the range
+	}
+	formatted := []rune(formattedText)
+
+	// Find the end (in the formatted text) of the text replaced by each edit.
+	editEnds := map[int]int{} // the index of the last edit that ends at each offset
+	delta := 0
+	for i, edit := range edits {
+		delta += len([]rune(edit.Content)) - edit.Length
+		editEnds[edit.Offset+edit.Length+delta] = i
+	}
+	formattedRangeEnd := rangeEnd + delta
+
+	type token struct {
+		kind        SyntaxKind
+		offset, end int // the offsets in the formatted text
+		line        int
+		col, endCol int
+		startsLine  bool
+	}
+	var tokens []token
+	line, lineStart, startsLine := 0, 0, true
+	multiLineComments := map[int]bool{} // the lines where multi-line block comments start
+	scanner := NewScanner(formattedText, ScanOptions{Trivia: true})
+	for kind := scanner.Scan(); kind != EOF; kind = scanner.Scan() {
+		t := token{kind: kind, offset: scanner.TokenOffset(), line: line, startsLine: startsLine}
+		t.end = t.offset + scanner.TokenLength()
+		t.col = t.offset - lineStart
+		switch kind {
+		case Trivia:
+			continue
+		case LineBreakTrivia:
+			line++
+			lineStart = t.end
+			startsLine = true
+			continue
+		case BlockCommentTrivia:
+			for i := t.offset; i < t.end; i++ {
+				if isLineBreak(formatted[i]) {
+					multiLineComments[t.line] = true
+					line++
+					lineStart = i + 1
+				}
+			}
+		}
+		t.endCol = t.end - lineStart
+		startsLine = false
+		tokens = append(tokens, t)
+	}
+
+	// Find the properties of the objects that start a line, and the gaps before
+	// their values and comments to align.
+	type property struct {
+		value   int // the index of the value token
+		comment int // the index of the first comment after the property on the same line, or -1
+	}
+	type block struct {
+		object     bool
+		offset     int
+		properties []property
+	}
+	type gap struct {
+		start, end int // the offsets in the formatted text
+		width      int
+	}
+	var blocks []block
+	var gaps []gap
+	for i, t := range tokens {
+		var top *block
+		if len(blocks) > 0 {
+			top = &blocks[len(blocks)-1]
+		}
+		switch t.kind {
+		case OpenBraceToken, OpenBracketToken:
+			blocks = append(blocks, block{object: t.kind == OpenBraceToken, offset: t.offset})
+		case CloseBraceToken, CloseBracketToken:
+			if top == nil {
+				break
+			}
+			blocks = blocks[:len(blocks)-1]
+			if !top.object || top.offset > formattedRangeEnd || t.end < rangeStart {
+				break
+			}
+			// Moving a multi-line block comment would change the indentation of
+			// its lines after the first, so don't align the lines with them.
+			properties := top.properties[:0]
+			for _, p := range top.properties {
+				if !multiLineComments[tokens[p.value].line] {
+					properties = append(properties, p)
+				}
+			}
+			top.properties = properties
+			shifts := make([]int, len(top.properties)) // the change in width of the gaps before the values
+			if options.AlignValues {
+				valueCol := 0
+				for _, p := range top.properties {
+					if col := tokens[p.value-1].endCol + 1; col > valueCol {
+						valueCol = col
+					}
+				}
+				for i, p := range top.properties {
+					colon, value := tokens[p.value-1], tokens[p.value]
+					width := valueCol - colon.endCol
+					gaps = append(gaps, gap{start: colon.end, end: value.offset, width: width})
+					shifts[i] = width - (value.col - colon.endCol)
+				}
+			}
+			if options.AlignComments {
+				commentCol := 0
+				for i, p := range top.properties {
+					if p.comment < 0 {
+						continue
+					}
+					if col := tokens[p.comment-1].endCol + shifts[i] + 1; col > commentCol {
+						commentCol = col
+					}
+				}
+				for i, p := range top.properties {
+					if p.comment < 0 {
+						continue
+					}
+					previous, comment := tokens[p.comment-1], tokens[p.comment]
+					gaps = append(gaps, gap{start: previous.end, end: comment.offset, width: commentCol - previous.endCol - shifts[i]})
+				}
+			}
+		case LineCommentTrivia, BlockCommentTrivia:
+			if top == nil || !top.object || len(top.properties) == 0 || t.startsLine {
+				break
+			}
+			if p := &top.properties[len(top.properties)-1]; p.comment < 0 && tokens[p.value].line == t.line && i > p.value {
+				p.comment = i
+			}
+		case StringLiteral:
+			if top == nil || !top.object || !t.startsLine || i+2 >= len(tokens) {
+				break
+			}
+			colon, value := tokens[i+1], tokens[i+2]
+			if colon.kind != ColonToken || colon.line != t.line || value.line != t.line {
+				break
+			}
+			switch value.kind {
+			case LineCommentTrivia, BlockCommentTrivia, CommaToken, ColonToken, CloseBraceToken, CloseBracketToken:
+				// not a value
+			default:
+				top.properties = append(top.properties, property{value: i + 2, comment: -1})
+			}
+		}
+	}
+
+	// Change the width of the gaps.
+	for _, g := range gaps {
+		spaces := strings.Repeat(" ", g.width)
+		switch {
+		case g.end <= rangeStart:
+			edits = append(edits, Edit{Offset: g.start, Length: g.end - g.start, Content: spaces})
+		case g.start >= formattedRangeEnd:
+			edits = append(edits, Edit{Offset: g.start - delta, Length: g.end - g.start, Content: spaces})
+		default:
+			i, ok := editEnds[g.end]
+			if !ok {
+				continue
+			}
+			content := edits[i].Content
+			if n := g.width - (g.end - g.start); n > 0 {
+				content += strings.Repeat(" ", n)
+			} else if n < 0 && strings.HasSuffix(content, strings.Repeat(" ", -n)) {
+				content = content[:len(content)+n]
+			}
+			edits[i].Content = content
+		}
+	}
+	sort.SliceStable(edits, func(i, j int) bool {
+		if edits[i].Offset != edits[j].Offset {
+			return edits[i].Offset < edits[j].Offset
+		}
+		return edits[i].Length == 0 && edits[j].Length != 0
+	})
+	return edits
+}
@@ -6,6 +6,7 @@
 package jsonx
 
 import (
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -91,6 +92,319 @@ func TestFormat(t *testing.T) {
 			want: `[
   null 1.2
 ]`},
+		"syntax errors - adjacent words": {
+			input: `[ true false foo ]`,
+			want: `[
+  true false foo
+]`},
+		"syntax errors - unterminated string": {
+			input: `[ "abc
+1 ]`,
+			want: `[
+  "abc
+  1
+]`},
+		"minify": {
+			input:   `{ "x" : [ 1, 2 ], /* c */ "y": { "z": null } }`,
+			options: &FormatOptions{Style: MinifyStyle},
+			want:    `{"x":[1,2],/* c */"y":{"z":null}}`},
+		"minify - line comment": {
+			input: `[ 1, // c
+  2 ]`,
+			options: &FormatOptions{Style: MinifyStyle, EOL: "\n"},
+			want: `[1,// c
+2]`},
+		"minify - remove comments and trailing commas": {
+			input: `{ "x": [ 1, 2, ], // c
+  "y": 3, /* c */ }`,
+			options: &FormatOptions{Style: MinifyStyle, RemoveComments: true, TrailingCommas: NeverTrailingCommas},
+			want:    `{"x":[1,2],"y":3}`},
+		"compact": {
+			input:   `{"x": [1, 2, 3], "y": {"a": "b"}, "z": [{"c": true}, {"d": false}]}`,
+			options: &FormatOptions{TabSize: 2, InsertSpaces: true, EOL: "\n", Style: CompactStyle, MaxLineWidth: 20},
+			want: `
+{
+  "x": [1, 2, 3],
+  "y": {"a": "b"},
+  "z": [
+    {"c": true},
+    {"d": false}
+  ]
+}`},
+		"compact - fits on one line": {
+			input: `{
+  "x": [ 1, 2 ],
+  "y": true
+}`,
+			options: &FormatOptions{TabSize: 2, InsertSpaces: true, EOL: "\n", Style: CompactStyle},
+			want:    `{"x": [1, 2], "y": true}`},
+		"compact - line comment forces line breaks": {
+			input: `{"x": [1, // c
+2], "y": [3, /* c */ 4]}`,
+			options: &FormatOptions{TabSize: 2, InsertSpaces: true, EOL: "\n", Style: CompactStyle},
+			want: `
+{
+  "x": [
+    1, // c
+    2
+  ],
+  "y": [3, /* c */ 4]
+}`},
+		"trailing commas - never": {
+			input:   `[ 1, 2, /* c */ ]`,
+			options: &FormatOptions{TabSize: 2, InsertSpaces: true, EOL: "\n", TrailingCommas: NeverTrailingCommas},
+			want: `
+[
+  1,
+  2 /* c */
+]`},
+		"trailing commas - always": {
+			input: `{"a": [1, 2], "b": {}, "c": [], // c
+}`,
+			options: &FormatOptions{TabSize: 2, InsertSpaces: true, EOL: "\n", TrailingCommas: AlwaysTrailingCommas},
+			want: `
+{
+  "a": [
+    1,
+    2,
+  ],
+  "b": {},
+  "c": [], // c
+}`},
+		"trailing commas - always, compact": {
+			input: `{"a": [1, 2], "b": {}, "c": [3, 4,
+  5]}`,
+			options: &FormatOptions{TabSize: 2, InsertSpaces: true, EOL: "\n", Style: CompactStyle, MaxLineWidth: 20, TrailingCommas: AlwaysTrailingCommas},
+			want: `
+{
+  "a": [1, 2],
+  "b": {},
+  "c": [3, 4, 5],
+}`},
+		"trailing commas - always, keep lines": {
+			input: `{"a": [1, 2], "b": [3,
+  4
+], "c": 5}`,
+			options: &FormatOptions{TabSize: 2, InsertSpaces: true, EOL: "\n", KeepLines: true, TrailingCommas: AlwaysTrailingCommas},
+			want: `
+{"a": [1, 2], "b": [3,
+    4,
+  ], "c": 5}`},
+		"trailing commas - always, minify": {
+			input:   `{"a": [1, 2], "b": {}}`,
+			options: &FormatOptions{Style: MinifyStyle, TrailingCommas: AlwaysTrailingCommas},
+			want:    `{"a":[1,2],"b":{}}`},
+		"keep lines": {
+			input: `{"a": 1, "b": [1,
+2], "c": {
+"d": true}}`,
+			options: &FormatOptions{TabSize: 2, InsertSpaces: true, EOL: "\n", KeepLines: true},
+			want: `
+{"a": 1, "b": [1,
+    2], "c": {
+    "d": true}}`},
+		"blank lines": {
+			input: `{
+
+  "a": 1,
+
+
+  "b": [1,
+
+    2] // c
+
+
+}`,
+			options: &FormatOptions{TabSize: 2, InsertSpaces: true, EOL: "\n", MaxBlankLines: 1},
+			want: `
+{
+
+  "a": 1,
+
+  "b": [
+    1,
+
+    2
+  ] // c
+
+}`},
+		"keep lines - blank lines": {
+			input: `{"a": 1,
+
+
+
+    "b": 2, "c": [
+
+  3]}`,
+			options: &FormatOptions{TabSize: 2, InsertSpaces: true, EOL: "\n", KeepLines: true, MaxBlankLines: 2},
+			want: `
+{"a": 1,
+
+
+  "b": 2, "c": [
+
+    3]}`},
+		"align values and comments": {
+			input: `{"a": 1, // c
+"long key": {"x": true, "yy": false}, "bb": [1, 2], // d
+/* e */ "ccc": null /* f */}`,
+			options: &FormatOptions{TabSize: 2, InsertSpaces: true, EOL: "\n", Style: CompactStyle, AlignValues: true, AlignComments: true},
+			want: `
+{
+  "a":        1,      // c
+  "long key": {"x": true, "yy": false},
+  "bb":       [1, 2], // d
+  /* e */ "ccc": null /* f */
+}`},
+		"align values - nested": {
+			input:   `{"a": {"b": 1, "ccc": 2}, "dd": 3}`,
+			options: &FormatOptions{TabSize: 2, InsertSpaces: true, EOL: "\n", AlignValues: true},
+			want: `
+{
+  "a":  {
+    "b":   1,
+    "ccc": 2
+  },
+  "dd": 3
+}`},
+		"align comments - range": {
+			input: `{
+  "a": 1, // c
+|  "bb": 2,  // d|
+  "ccc": 3 // e
+}`,
+			options: &FormatOptions{TabSize: 2, InsertSpaces: true, EOL: "\n", AlignComments: true},
+			want: `
+{
+  "a": 1,  // c
+  "bb": 2, // d
+  "ccc": 3 // e
+}`},
+		"block comment - reindent nested": {
+			input: `{
+"a": {
+/* first
+   second
+     third */
+"b": 1
+}
+}`,
+			want: `
+{
+  "a": {
+    /* first
+       second
+         third */
+    "b": 1
+  }
+}`},
+		"block comment - reindent to the left": {
+			input: `{
+        "a": [
+                /*
+                 * first
+                 */
+                1
+        ]
+}`,
+			want: `
+{
+  "a": [
+    /*
+     * first
+     */
+    1
+  ]
+}`},
+		"block comment - reindent after a token": {
+			input: `{"a": {"b":    /* first
+                 second */ 1}}`,
+			want: `
+{
+  "a": {
+    "b": /* first
+           second */ 1
+  }
+}`},
+		"block comment - reindent with tabs": {
+			input:   "{\n\"a\": {\n/* first\n  second */\n\"b\": 1\n}\n}",
+			options: &FormatOptions{TabSize: 2, InsertSpaces: false, EOL: "\n"},
+			want:    "{\n\t\"a\": {\n\t\t/* first\n\t\t\tsecond */\n\t\t\"b\": 1\n\t}\n}"},
+		"block comment - keep indentation": {
+			input: `{
+"a": {
+/* first
+   second */
+"b": 1
+}
+}`,
+			options: &FormatOptions{TabSize: 2, InsertSpaces: true, EOL: "\n", KeepBlockCommentIndentation: true},
+			want: `
+{
+  "a": {
+    /* first
+   second */
+    "b": 1
+  }
+}`},
+		"insert final newline": {
+			input:   `{"a": 1}`,
+			options: &FormatOptions{TabSize: 2, InsertSpaces: true, EOL: "\n", InsertFinalNewline: true},
+			want: `
+{
+  "a": 1
+}
+`},
+		"sort keys": {
+			input: `{
+  // c
+  "c": {"z": 1, "y": 2},
+  /* b */ "b": 2, // b
+  "a": [{"k": 1, "j": 2}] // a
+}`,
+			options: &FormatOptions{TabSize: 2, InsertSpaces: true, EOL: "\n", SortKeys: true},
+			want: `
+{
+  "a": [
+    {
+      "j": 2,
+      "k": 1
+    }
+  ], // a
+  /* b */ "b": 2, // b
+  // c
+  "c": {
+    "y": 2,
+    "z": 1
+  }
+}`},
+		"sort keys - custom order": {
+			input: `{"a": 1, "c": 3, "B": 2,}`,
+			options: &FormatOptions{TabSize: 2, InsertSpaces: true, EOL: "\n", SortKeys: true, KeyLess: func(a, b string) bool {
+				return strings.ToLower(a) < strings.ToLower(b)
+			}},
+			want: `
+{
+  "a": 1,
+  "B": 2,
+  "c": 3,
+}`},
+		"sort keys - range": {
+			input: `{
+  "b": 1,
+|  "z": {"d": 1, "c": 2}|,
+  "a": 3
+}`,
+			options: &FormatOptions{TabSize: 2, InsertSpaces: true, EOL: "\n", SortKeys: true},
+			want: `
+{
+  "b": 1,
+  "z": {
+    "c": 2,
+    "d": 1
+  },
+  "a": 3
+}`},
 		"empty lines": {
 			input: `{
 "a": true,
@@ -129,6 +443,13 @@ func TestFormat(t *testing.T) {
  } `,
 			want: `{
   "a": {} // comment 你好
+}`},
+		"single line comment after colon": {
+			input: `{ "a": // comment
+1 }`,
+			want: `{
+  "a": // comment
+  1
 }`},
 		"single line comment on same line 2": {
 			input: `{ //comment 你好
@@ -203,6 +524,18 @@ func TestFormat(t *testing.T) {
 	],
 	"c": {}
 }`},
+		"detected indentation": {
+			input:   "{\n\t\"a\": [1]\n}\n",
+			options: &FormatOptions{},
+			want:    "{\n\t\"a\": [\n\t\t1\n\t]\n}"},
+		"detected indentation - explicit options": {
+			input:   "{\n\t\"a\": [1]\n}\n",
+			options: &FormatOptions{InsertSpaces: true, InsertFinalNewline: true},
+			want:    "{\n    \"a\": [\n        1\n    ]\n}\n"},
+		"detected indentation - explicit tabs": {
+			input:   "{\n  \"a\": [1]\n}",
+			options: &FormatOptions{TabSize: 4, InsertSpaces: false},
+			want:    "{\n\t\"a\": [\n\t\t1\n\t]\n}"},
 		"block comment none-line breaking symbols": {
 			input: `{ "a": [ 1
 /* comment 你好 */
@@ -271,9 +604,152 @@ null,
 			if err != nil {
 				t.Fatal(err)
 			}
-			if want := strings.TrimPrefix(test.want, "\n"); output != want {
+			want := strings.TrimPrefix(test.want, "\n")
+			if output != want {
 				t.Errorf("formatted\ngot  %s\nwant %s", output, want)
 			}
+			if input != test.input {
+				return // range formatting
+			}
+
+			output, err = FormatDocument(input, *options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if output != want {
+				t.Errorf("FormatDocument\ngot  %s\nwant %s", output, want)
+			}
+			if !IsFormatted(want, *options) {
+				t.Errorf("IsFormatted(%q) = false, want true", want)
+			}
+			if got, want := IsFormatted(input, *options), input == want; got != want {
+				t.Errorf("IsFormatted(%q) = %v, want %v", input, got, want)
+			}
 		})
 	}
 }
+
+func TestFormat_sortKeysEdits(t *testing.T) {
+	tests := map[string]struct {
+		input string
+		want  []Edit
+	}{
+		"unchanged lines": {
+			input: "{\n  \"a\": 1,\n  \"c\": 3,\n  \"b\": 2,\n  \"d\": 4\n}",
+			want:  []Edit{{Offset: 12, Length: 20, Content: "  \"b\": 2,\n  \"c\": 3,\n"}},
+		},
+		"formatting outside the object": {
+			input: "{\n\"a\": 1,\n  \"o\": {\n    \"c\": 3,\n    \"b\": 2\n  },\n  \"z\":  true\n}",
+			want: []Edit{
+				{Offset: 1, Length: 1, Content: "\n  "},
+				{Offset: 19, Length: 23, Content: "    \"b\": 2,\n    \"c\": 3\n"},
+				{Offset: 53, Length: 2, Content: " "},
+			},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			options := FormatOptions{TabSize: 2, InsertSpaces: true, EOL: "\n", SortKeys: true}
+			if edits := Format(test.input, options); !reflect.DeepEqual(edits, test.want) {
+				t.Errorf("got edits %+v, want %+v", edits, test.want)
+			}
+		})
+	}
+}
+
+func TestDetectFormatOptions(t *testing.T) {
+	tests := map[string]struct {
+		input string
+		want  FormatOptions
+	}{
+		"empty": {
+			input: "",
+			want:  FormatOptions{TabSize: 4, InsertSpaces: true, EOL: "\n"},
+		},
+		"no indentation": {
+			input: `{"a": [1, 2]}`,
+			want:  FormatOptions{TabSize: 4, InsertSpaces: true, EOL: "\n"},
+		},
+		"2 spaces": {
+			input: "{\n  \"a\": [\n    1\n  ]\n}\n",
+			want:  FormatOptions{TabSize: 2, InsertSpaces: true, EOL: "\n", InsertFinalNewline: true},
+		},
+		"3 spaces nested on one line": {
+			input: "[[\n      1\n]]",
+			want:  FormatOptions{TabSize: 3, InsertSpaces: true, EOL: "\n"},
+		},
+		"tabs": {
+			input: "{\r\n\t\"a\": 1, // c\r\n\t\"b\": 2\r\n}",
+			want:  FormatOptions{TabSize: 4, InsertSpaces: false, EOL: "\r\n"},
+		},
+		"mostly spaces": {
+			input: "{\n  \"a\": 1,\n\t\"b\": 2,\n  \"c\": {\n      \"d\": 3\n  }\n}",
+			want:  FormatOptions{TabSize: 2, InsertSpaces: true, EOL: "\n"},
+		},
+		"ignores comment lines": {
+			input: "{\n  /*\n   * c\n   */\n  \"a\": 1\n}",
+			want:  FormatOptions{TabSize: 2, InsertSpaces: true, EOL: "\n"},
+		},
+	}
+	for label, test := range tests {
+		t.Run(label, func(t *testing.T) {
+			got := DetectFormatOptions(test.input)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func FuzzFormat(f *testing.F) {
+	for _, input := range fuzzSeedCorpus(f) {
+		f.Add(input, uint8(2), true, uint8(PrettyStyle), uint8(0))
+		f.Add(input, uint8(2), true, uint8(CompactStyle), uint8(0))
+		f.Add(input, uint8(2), true, uint8(MinifyStyle), uint8(0))
+		f.Add(input, uint8(2), true, uint8(PrettyStyle), uint8(0x0f))
+		f.Add(input, uint8(2), true, uint8(CompactStyle), uint8(0x60))
+	}
+	f.Fuzz(func(t *testing.T, input string, tabSize uint8, insertSpaces bool, style, flags uint8) {
+		options := FormatOptions{
+			TabSize:                     int(tabSize % 9),
+			InsertSpaces:                insertSpaces,
+			EOL:                         "\n",
+			Style:                       FormatStyle(style % 3),
+			MaxLineWidth:                int(tabSize),
+			RemoveComments:              style&0x10 != 0,
+			TrailingCommas:              []TrailingCommaPolicy{PreserveTrailingCommas, NeverTrailingCommas, AlwaysTrailingCommas}[(style>>5)%3],
+			KeepLines:                   flags&0x01 != 0,
+			InsertFinalNewline:          flags&0x02 != 0,
+			SortKeys:                    flags&0x04 != 0,
+			MaxBlankLines:               int(flags>>3) & 0x03,
+			AlignValues:                 flags&0x20 != 0,
+			AlignComments:               flags&0x40 != 0,
+			KeepBlockCommentIndentation: flags&0x80 != 0,
+		}
+		formatted, err := FormatDocument(input, options)
+		if err != nil {
+			t.Fatalf("%q: FormatDocument: %s", input, err)
+		}
+		options = detectUnsetFormatOptions(input, options) // the formatted document may be detected differently
+		again, err := FormatDocument(formatted, options)
+		if err != nil {
+			t.Fatalf("%q: FormatDocument: %s", formatted, err)
+		}
+		if again != formatted {
+			t.Fatalf("%q: formatting is not idempotent\nonce  %q\ntwice %q", input, formatted, again)
+		}
+		if !IsFormatted(formatted, options) {
+			t.Fatalf("%q: IsFormatted(%q) = false", input, formatted)
+		}
+		if IsFormatted(input, options) != (input == formatted) {
+			t.Fatalf("%q: IsFormatted = %v", input, !(input == formatted))
+		}
+
+		parseOptions := ParseOptions{Comments: true, TrailingCommas: true}
+		if want, errors := Parse(input, parseOptions); len(errors) == 0 {
+			if got, _ := Parse(formatted, parseOptions); string(got) != string(want) {
+				t.Fatalf("%q: formatting changed the value\ngot  %s\nwant %s", input, got, want)
+			}
+		}
+	})
+}
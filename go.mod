@@ -1,5 +1,5 @@
 module github.com/sourcegraph/jsonx
 
-go 1.14
+go 1.18
 
 require golang.org/x/tools v0.0.0-20200624163319-25775e59acb7 // indirect
@@ -0,0 +1,327 @@
+// Package gostruct generates Go type declarations for the values of sample JSON
+// documents with comments and trailing commas (JSONC).
+package gostruct
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/sourcegraph/jsonx"
+	"github.com/sourcegraph/jsonx/internal/comments"
+)
+
+// Options specifies options for generating Go types.
+type Options struct {
+	TypeName string // the name of the type of the document's root value (default "Config")
+	Package  string // the name of the package clause to generate (none if empty)
+}
+
+// Generate returns the gofmt-formatted Go type declarations for the value of the
+// sample JSON document text, starting with the type of the root value.
+//
+// Objects are generated as struct types with json tags, named after their
+// properties (in singular for array elements). The types of the elements of an
+// array are merged: the properties of objects are combined, and properties that are
+// missing from some of the objects are optional (with omitempty). Numbers are int if
+// they are all integers and float64 otherwise, values that are null in some places
+// are pointers, and values with different types are interface{}.
+//
+// Comments on the lines before a property become the doc comment of its field, and
+// comments before the root value become the doc comment of its type.
+func Generate(text string, options Options) ([]byte, error) {
+	root, errors := jsonx.ParseTree(text, jsonx.ParseOptions{Comments: true, TrailingCommas: true})
+	if len(errors) > 0 {
+		return nil, fmt.Errorf("invalid JSON document: %s", errors[0])
+	}
+	if root == nil {
+		return nil, fmt.Errorf("empty JSON document")
+	}
+	typeName := options.TypeName
+	if typeName == "" {
+		typeName = "Config"
+	}
+
+	g := &generator{comments: comments.Leading(text), names: map[string]bool{}}
+	s := &shape{}
+	g.merge(s, root)
+
+	var buf bytes.Buffer
+	if options.Package != "" {
+		fmt.Fprintf(&buf, "package %s\n\n", options.Package)
+	}
+	g.names[typeName] = true
+	g.decls = append(g.decls, decl{name: typeName, shape: s, comment: g.comments[root.Offset]})
+	for i := 0; i < len(g.decls); i++ { // g.decls grows as struct types are named
+		d := g.decls[i]
+		t := g.structType(d.shape)
+		if i == 0 && !(d.shape.object && !d.shape.null && d.shape.kinds() == 1) {
+			t = g.goType(d.shape, d.name, false) // the root value is not always an object
+		}
+		if i > 0 {
+			buf.WriteString("\n")
+		}
+		writeComment(&buf, "", d.comment)
+		fmt.Fprintf(&buf, "type %s %s\n", d.name, t)
+	}
+	return format.Source(buf.Bytes())
+}
+
+// A shape is the merged type of one or more JSON values.
+type shape struct {
+	null, boolean, integer, float, str, object, array bool // the types of the values
+
+	// objects
+	objects int      // the number of objects
+	fields  []*field // in order of appearance
+
+	// arrays
+	elem *shape // the merged type of the elements
+}
+
+type field struct {
+	name    string // the property name
+	shape   *shape
+	count   int    // the number of objects with the property
+	comment string // the comments before the property
+}
+
+// A decl is a struct type declaration to generate.
+type decl struct {
+	name    string
+	shape   *shape
+	comment string
+}
+
+type generator struct {
+	comments map[int]string // the comments before the tokens, by token offset
+	decls    []decl
+	names    map[string]bool // the names of the declared types
+}
+
+// merge merges the type of the value of the node into the shape.
+func (g *generator) merge(s *shape, node *jsonx.Node) {
+	switch node.Type {
+	case jsonx.Null:
+		s.null = true
+	case jsonx.Boolean:
+		s.boolean = true
+	case jsonx.String:
+		s.str = true
+	case jsonx.Number:
+		if _, err := strconv.ParseInt(string(node.Value.(json.Number)), 10, 64); err == nil {
+			s.integer = true
+		} else {
+			s.float = true
+		}
+	case jsonx.Array:
+		s.array = true
+		if s.elem == nil {
+			s.elem = &shape{}
+		}
+		for _, child := range node.Children {
+			g.merge(s.elem, child)
+		}
+	case jsonx.Object:
+		s.object = true
+		s.objects++
+		seen := map[string]bool{}
+		for _, property := range node.Children {
+			key := property.Children[0]
+			name := key.Value.(string)
+			if seen[name] || len(property.Children) < 2 {
+				continue
+			}
+			seen[name] = true
+			f := s.field(name)
+			f.count++
+			if f.comment == "" {
+				f.comment = g.comments[key.Offset]
+			}
+			g.merge(f.shape, property.Children[1])
+		}
+	}
+}
+
+// field returns the field of the object shape with the property name, adding it if
+// necessary.
+func (s *shape) field(name string) *field {
+	for _, f := range s.fields {
+		if f.name == name {
+			return f
+		}
+	}
+	f := &field{name: name, shape: &shape{}}
+	s.fields = append(s.fields, f)
+	return f
+}
+
+// kinds returns the number of the types of the values other than null, counting
+// integers and floats as one.
+func (s *shape) kinds() int {
+	n := 0
+	for _, b := range []bool{s.boolean, s.integer || s.float, s.str, s.object, s.array} {
+		if b {
+			n++
+		}
+	}
+	return n
+}
+
+// goType returns the Go type for the shape. The name is the name to give to a
+// struct type, and pointer is whether to make the type a pointer if the values can
+// be null.
+func (g *generator) goType(s *shape, name string, pointer bool) string {
+	var t string
+	switch {
+	case s.kinds() != 1:
+		return "interface{}" // only null, or different types
+	case s.boolean:
+		t = "bool"
+	case s.float:
+		t = "float64"
+	case s.integer:
+		t = "int"
+	case s.str:
+		t = "string"
+	case s.array:
+		elemName := singular(name)
+		if s.elem.array {
+			elemName = name // the elements of the nested arrays are named in singular
+		}
+		return "[]" + g.goType(s.elem, elemName, false)
+	case s.object:
+		t = g.declare(s, name)
+	}
+	if pointer && s.null {
+		t = "*" + t
+	}
+	return t
+}
+
+// declare adds a struct type declaration for the object shape, with a name based
+// on the name (which must be an exported identifier), and returns its name.
+func (g *generator) declare(s *shape, name string) string {
+	unique := name
+	for i := 2; g.names[unique]; i++ {
+		unique = name + strconv.Itoa(i)
+	}
+	g.names[unique] = true
+	g.decls = append(g.decls, decl{name: unique, shape: s})
+	return unique
+}
+
+// structType returns the struct type for the object shape.
+func (g *generator) structType(s *shape) string {
+	var buf bytes.Buffer
+	buf.WriteString("struct {\n")
+	names := map[string]bool{}
+	for _, f := range s.fields {
+		name := identifier(f.name)
+		unique := name
+		for i := 2; names[unique]; i++ {
+			unique = name + strconv.Itoa(i)
+		}
+		names[unique] = true
+
+		tag := f.name
+		if f.count < s.objects {
+			tag += ",omitempty"
+		}
+		writeComment(&buf, "\t", f.comment)
+		fmt.Fprintf(&buf, "\t%s %s %s\n", unique, g.goType(f.shape, name, true), structTag("json:"+strconv.Quote(tag)))
+	}
+	buf.WriteString("}")
+	return buf.String()
+}
+
+// structTag returns the Go literal for the struct tag.
+func structTag(tag string) string {
+	if strings.Contains(tag, "`") {
+		return strconv.Quote(tag)
+	}
+	return "`" + tag + "`"
+}
+
+func writeComment(buf *bytes.Buffer, indent, comment string) {
+	if comment == "" {
+		return
+	}
+	for _, line := range strings.Split(comment, "\n") {
+		if line == "" {
+			fmt.Fprintf(buf, "%s//\n", indent)
+		} else {
+			fmt.Fprintf(buf, "%s// %s\n", indent, line)
+		}
+	}
+}
+
+// commonInitialisms are the words that are written in upper case in Go identifiers.
+var commonInitialisms = map[string]bool{
+	"API": true, "ASCII": true, "CPU": true, "CSS": true, "DNS": true, "EOF": true,
+	"GUID": true, "HTML": true, "HTTP": true, "HTTPS": true, "ID": true, "IP": true,
+	"JSON": true, "LHS": true, "QPS": true, "RAM": true, "RHS": true, "RPC": true,
+	"SLA": true, "SMTP": true, "SQL": true, "SSH": true, "TCP": true, "TLS": true,
+	"TTL": true, "UDP": true, "UI": true, "UID": true, "URI": true, "URL": true,
+	"UTF8": true, "UUID": true, "VM": true, "XML": true, "XSRF": true, "XSS": true,
+}
+
+// identifier returns an exported Go identifier for the property name, such as
+// TabSize for "tabSize", "tab_size" and "tab-size", and UserID for "userId".
+func identifier(name string) string {
+	// Split the name into words at non-alphanumeric characters and at lower-case
+	// to upper-case transitions.
+	var words []string
+	var word []rune
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if len(word) > 0 {
+				words = append(words, string(word))
+				word = nil
+			}
+			continue
+		}
+		if len(word) > 0 && unicode.IsUpper(r) && !unicode.IsUpper(word[len(word)-1]) {
+			words = append(words, string(word))
+			word = nil
+		}
+		word = append(word, r)
+	}
+	if len(word) > 0 {
+		words = append(words, string(word))
+	}
+
+	var b strings.Builder
+	for _, w := range words {
+		if upper := strings.ToUpper(w); commonInitialisms[upper] {
+			b.WriteString(upper)
+			continue
+		}
+		runes := []rune(w)
+		b.WriteRune(unicode.ToUpper(runes[0]))
+		b.WriteString(string(runes[1:]))
+	}
+	id := b.String()
+	if id == "" || !unicode.IsUpper([]rune(id)[0]) {
+		id = "X" + id // a digit or a letter without an upper case, so not exported
+	}
+	return id
+}
+
+// singular returns the singular of the English plural noun (in a simple way), or the
+// noun followed by "Item" if it is not plural.
+func singular(noun string) string {
+	switch {
+	case strings.HasSuffix(noun, "ies") && len(noun) > 3:
+		return noun[:len(noun)-3] + "y"
+	case strings.HasSuffix(noun, "sses"), strings.HasSuffix(noun, "xes"), strings.HasSuffix(noun, "ches"), strings.HasSuffix(noun, "shes"):
+		return noun[:len(noun)-2]
+	case strings.HasSuffix(noun, "s") && !strings.HasSuffix(noun, "ss") && len(noun) > 1:
+		return noun[:len(noun)-1]
+	}
+	return noun + "Item"
+}
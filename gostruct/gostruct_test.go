@@ -0,0 +1,141 @@
+package gostruct
+
+import "testing"
+
+func TestGenerate(t *testing.T) {
+	tests := map[string]struct {
+		input   string
+		options Options
+		want    string
+	}{
+		"object": {
+			input: `{"name": "x", "tabSize": 2, "ratio": 0.5, "enabled": true, "extra": null}`,
+			want: "type Config struct {\n" +
+				"\tName    string      `json:\"name\"`\n" +
+				"\tTabSize int         `json:\"tabSize\"`\n" +
+				"\tRatio   float64     `json:\"ratio\"`\n" +
+				"\tEnabled bool        `json:\"enabled\"`\n" +
+				"\tExtra   interface{} `json:\"extra\"`\n" +
+				"}\n",
+		},
+		"options": {
+			input:   `{}`,
+			options: Options{TypeName: "Settings", Package: "p"},
+			want:    "package p\n\ntype Settings struct {\n}\n",
+		},
+		"array elements": {
+			input: `{"servers": [
+				{"host": "a", "port": 80, "weight": 1},
+				{"host": "b", "port": null, "weight": 1.5, "tags": ["x"]},
+			]}`,
+			want: "type Config struct {\n" +
+				"\tServers []Server `json:\"servers\"`\n" +
+				"}\n\n" +
+				"type Server struct {\n" +
+				"\tHost   string   `json:\"host\"`\n" +
+				"\tPort   *int     `json:\"port\"`\n" +
+				"\tWeight float64  `json:\"weight\"`\n" +
+				"\tTags   []string `json:\"tags,omitempty\"`\n" +
+				"}\n",
+		},
+		"mixed and empty arrays": {
+			input: `{"mixed": [1, "a"], "empty": [], "entries": [[{"a": 1}]]}`,
+			want: "type Config struct {\n" +
+				"\tMixed   []interface{} `json:\"mixed\"`\n" +
+				"\tEmpty   []interface{} `json:\"empty\"`\n" +
+				"\tEntries [][]Entry     `json:\"entries\"`\n" +
+				"}\n\n" +
+				"type Entry struct {\n" +
+				"\tA int `json:\"a\"`\n" +
+				"}\n",
+		},
+		"nested objects and names": {
+			input: `{"user_id": 1, "http-url": "", "2fa": true, "a": {"config": {}}, "b": {"a": {}}}`,
+			want: "type Config struct {\n" +
+				"\tUserID  int    `json:\"user_id\"`\n" +
+				"\tHTTPURL string `json:\"http-url\"`\n" +
+				"\tX2fa    bool   `json:\"2fa\"`\n" +
+				"\tA       A      `json:\"a\"`\n" +
+				"\tB       B      `json:\"b\"`\n" +
+				"}\n\n" +
+				"type A struct {\n" +
+				"\tConfig Config2 `json:\"config\"`\n" +
+				"}\n\n" +
+				"type B struct {\n" +
+				"\tA A2 `json:\"a\"`\n" +
+				"}\n\n" +
+				"type Config2 struct {\n" +
+				"}\n\n" +
+				"type A2 struct {\n" +
+				"}\n",
+		},
+		"comments": {
+			input: `// The configuration.
+{
+	// The name.
+	"name": "x", // not a doc comment
+	/*
+	 * The size
+	 * in bytes.
+	 */
+	"size": 1,
+}`,
+			want: "// The configuration.\n" +
+				"type Config struct {\n" +
+				"\t// The name.\n" +
+				"\tName string `json:\"name\"`\n" +
+				"\t// The size\n" +
+				"\t// in bytes.\n" +
+				"\tSize int `json:\"size\"`\n" +
+				"}\n",
+		},
+		"root array": {
+			input: `[{"a": 1}, {}]`,
+			want: "type Config []ConfigItem\n\n" +
+				"type ConfigItem struct {\n" +
+				"\tA int `json:\"a,omitempty\"`\n" +
+				"}\n",
+		},
+		"root value": {
+			input: `"x"`,
+			want:  "type Config string\n",
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := Generate(test.input, test.options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != test.want {
+				t.Errorf("got\n%s\nwant\n%s", got, test.want)
+			}
+		})
+	}
+
+	t.Run("invalid document", func(t *testing.T) {
+		if _, err := Generate(`{"a": }`, Options{}); err == nil {
+			t.Error("got no error")
+		}
+	})
+}
+
+func TestIdentifier(t *testing.T) {
+	tests := map[string]string{
+		"tabSize":    "TabSize",
+		"tab_size":   "TabSize",
+		"tab-size":   "TabSize",
+		"userId":     "UserID",
+		"apiURL":     "APIURL",
+		"$schema":    "Schema",
+		"2fa":        "X2fa",
+		"":           "X",
+		"élan vital": "ÉlanVital",
+		"名字":         "X名字",
+	}
+	for name, want := range tests {
+		if got := identifier(name); got != want {
+			t.Errorf("identifier(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+// Package comments extracts the comments of JSON documents.
+package comments
+
+import (
+	"strings"
+
+	"github.com/sourcegraph/jsonx"
+)
+
+// Leading returns the comments on the lines before the tokens of the JSON document
+// text, by token offset. The comments are without the comment markers, and joined
+// with newlines. Comments after a token on the same line are ignored.
+func Leading(text string) map[int]string {
+	comments := map[int]string{}
+	scanner := jsonx.NewScanner(text, jsonx.ScanOptions{Trivia: true})
+	chars := []rune(text)
+	var pending []string // the comments since the last line break after a token
+	afterToken := false  // whether there was a token on the current line
+	for {
+		switch scanner.Scan() {
+		case jsonx.EOF:
+			return comments
+		case jsonx.Trivia:
+		case jsonx.LineBreakTrivia:
+			afterToken = false
+		case jsonx.LineCommentTrivia, jsonx.BlockCommentTrivia:
+			if !afterToken {
+				comment := string(chars[scanner.TokenOffset() : scanner.TokenOffset()+scanner.TokenLength()])
+				pending = append(pending, commentText(comment))
+			}
+		default:
+			if len(pending) > 0 {
+				comments[scanner.TokenOffset()] = strings.Join(pending, "\n")
+				pending = nil
+			}
+			afterToken = true
+		}
+	}
+}
+
+// commentText returns the text of the line or block comment, without the comment
+// markers and the leading asterisks of the lines of a block comment.
+func commentText(comment string) string {
+	if strings.HasPrefix(comment, "//") {
+		return strings.TrimSpace(strings.TrimPrefix(comment, "//"))
+	}
+	comment = strings.TrimSuffix(strings.TrimPrefix(comment, "/*"), "*/")
+	var lines []string
+	for _, line := range strings.Split(comment, "\n") {
+		line = strings.TrimSpace(line)
+		line = strings.TrimSpace(strings.TrimPrefix(line, "*"))
+		lines = append(lines, line)
+	}
+	// Remove the leading and trailing blank lines.
+	for len(lines) > 0 && lines[0] == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,25 @@
+// Package lineindex converts between the character offsets in a document and its
+// lines.
+package lineindex
+
+import "sort"
+
+// An Index is the character offsets of the start of each line of a document. A
+// line ends with "\n", "\r\n" or "\r".
+type Index []int
+
+// New returns the Index of the document's characters.
+func New(chars []rune) Index {
+	lines := Index{0}
+	for i, ch := range chars {
+		if ch == '\n' || ch == '\r' && (i+1 == len(chars) || chars[i+1] != '\n') {
+			lines = append(lines, i+1)
+		}
+	}
+	return lines
+}
+
+// Line returns the zero-based line of the character offset.
+func (lines Index) Line(offset int) int {
+	return sort.Search(len(lines), func(i int) bool { return lines[i] > offset }) - 1
+}
@@ -6,7 +6,13 @@
 package jsonx
 
 import (
+	"encoding/json"
 	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"strconv"
 	"testing"
 )
 
@@ -26,6 +32,7 @@ func TestParser(t *testing.T) {
 		`"foo"`:                     {want: `"foo"`},
 		`"\"-\\-\/-\b-\f-\n-\r-\t"`: {want: `"\"-\\-/-\u0008-\u000c-\n-\r-\t"`},
 		`"\u00DC"`:                  {want: `"Ü"`},
+		`"\ud83d\ude00"`:            {want: `"😀"`},
 		"9":                         {want: "9"},
 		"-9":                        {want: "-9"},
 		"0.129":                     {want: "0.129"},
@@ -64,6 +71,7 @@ func TestParser(t *testing.T) {
 		"[ 1 2, 3]":     {want: "[1,2,3]", errors: true},
 		"[ ,1, 2, 3 ]":  {want: "[1,2,3]", errors: true},
 		"[ ,1, 2, 3, ]": {options: &ParseOptions{TrailingCommas: false}, want: "[1,2,3]", errors: true},
+		"[ 1., 2 ]":     {want: "[2]", errors: true},
 
 		// disallow commments
 		`[ 1, 2, null, "foo" ]`:         {options: &ParseOptions{Comments: false}, want: `[1,2,null,"foo"]`},
@@ -102,3 +110,126 @@ func TestParser(t *testing.T) {
 		}
 	}
 }
+
+// fuzzSeedCorpus returns the seed corpus of the fuzz tests: the inputs of the
+// TestParser, TestScanner and TestFormat table tests, read from their source.
+func fuzzSeedCorpus(tb testing.TB) []string {
+	var corpus []string
+	for _, test := range []struct{ file, name string }{
+		{"parser_test.go", "TestParser"},
+		{"scanner_test.go", "TestScanner"},
+		{"format_test.go", "TestFormat"},
+	} {
+		file, err := parser.ParseFile(token.NewFileSet(), test.file, nil, 0)
+		if err != nil {
+			tb.Fatal(err)
+		}
+		var fn *ast.FuncDecl
+		for _, decl := range file.Decls {
+			if decl, ok := decl.(*ast.FuncDecl); ok && decl.Name.Name == test.name {
+				fn = decl
+			}
+		}
+		if fn == nil {
+			tb.Fatalf("%s: no %s", test.file, test.name)
+		}
+		ast.Inspect(fn, func(node ast.Node) bool {
+			// The table is assigned to tests, and the inputs are its keys or the
+			// input fields of its values.
+			assign, ok := node.(*ast.AssignStmt)
+			if !ok || len(assign.Lhs) != 1 || len(assign.Rhs) != 1 || !isIdent(assign.Lhs[0], "tests") {
+				return true
+			}
+			table, ok := assign.Rhs[0].(*ast.CompositeLit)
+			if !ok {
+				return true
+			}
+			for _, elt := range table.Elts {
+				elt := elt.(*ast.KeyValueExpr)
+				input := elt.Key
+				if value, ok := elt.Value.(*ast.CompositeLit); ok {
+					for _, field := range value.Elts {
+						if field, ok := field.(*ast.KeyValueExpr); ok && isIdent(field.Key, "input") {
+							input = field.Value
+						}
+					}
+				}
+				if s, ok := stringValue(input); ok {
+					corpus = append(corpus, s)
+				}
+			}
+			return false
+		})
+	}
+	if len(corpus) == 0 {
+		tb.Fatal("no table test inputs")
+	}
+	return corpus
+}
+
+func isIdent(expr ast.Expr, name string) bool {
+	ident, ok := expr.(*ast.Ident)
+	return ok && ident.Name == name
+}
+
+// stringValue returns the value of a string literal, or of a concatenation of string
+// literals.
+func stringValue(expr ast.Expr) (string, bool) {
+	switch expr := expr.(type) {
+	case *ast.BasicLit:
+		if expr.Kind == token.STRING {
+			s, err := strconv.Unquote(expr.Value)
+			return s, err == nil
+		}
+	case *ast.BinaryExpr:
+		if expr.Op == token.ADD {
+			x, okX := stringValue(expr.X)
+			y, okY := stringValue(expr.Y)
+			return x + y, okX && okY
+		}
+	case *ast.ParenExpr:
+		return stringValue(expr.X)
+	}
+	return "", false
+}
+
+func FuzzParse(f *testing.F) {
+	for _, input := range fuzzSeedCorpus(f) {
+		f.Add(input, true, true)
+	}
+	f.Fuzz(func(t *testing.T, input string, comments, trailingCommas bool) {
+		output, _ := Parse(input, ParseOptions{Comments: comments, TrailingCommas: trailingCommas})
+		if output != nil && !json.Valid(output) {
+			t.Fatalf("%q: Parse returned invalid JSON %s", input, output)
+		}
+	})
+}
+
+// FuzzParseDifferential checks that for valid (strict) JSON input, Parse
+// agrees with encoding/json.
+func FuzzParseDifferential(f *testing.F) {
+	for _, input := range fuzzSeedCorpus(f) {
+		f.Add(input)
+	}
+	f.Fuzz(func(t *testing.T, input string) {
+		if !json.Valid([]byte(input)) {
+			return
+		}
+		var want interface{}
+		if err := json.Unmarshal([]byte(input), &want); err != nil {
+			return // valid syntax, but not representable (e.g., number out of range)
+		}
+
+		output, errors := Parse(input, ParseOptions{})
+		if len(errors) != 0 {
+			t.Fatalf("%q: got parse errors %v for valid JSON", input, errors)
+		}
+		var got interface{}
+		if err := json.Unmarshal(output, &got); err != nil {
+			t.Fatalf("%q: Parse returned invalid JSON %s: %s", input, output, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("%q: Parse returned %s, want semantically equal to input", input, output)
+		}
+	})
+}
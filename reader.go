@@ -0,0 +1,365 @@
+package jsonx
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// A TokenKind is a kind of token returned by a Reader.
+type TokenKind int
+
+// Token kinds
+const (
+	TokenEOF         TokenKind = iota // the end of the document (or the first syntax error)
+	TokenObjectBegin                  // an open brace
+	TokenObjectEnd                    // a close brace
+	TokenArrayBegin                   // an open bracket
+	TokenArrayEnd                     // a close bracket
+	TokenProperty                     // an object property name
+	TokenString                       // a string value
+	TokenNumber                       // a number value
+	TokenBool                         // true or false
+	TokenNull                         // null
+)
+
+var tokenKindNames = [...]string{"EOF", "ObjectBegin", "ObjectEnd", "ArrayBegin", "ArrayEnd", "Property", "String", "Number", "Bool", "Null"}
+
+func (k TokenKind) String() string {
+	if k < 0 || int(k) >= len(tokenKindNames) {
+		return "TokenKind(" + strconv.Itoa(int(k)) + ")"
+	}
+	return "Token" + tokenKindNames[k]
+}
+
+// A Token is a token of a JSON document returned by a Reader.
+//
+// The Path field refers to memory owned by the Reader, and it is only valid until
+// the next call to Next.
+type Token struct {
+	Kind   TokenKind
+	Offset int    // character offset of the token in the document
+	Length int    // the length (in characters) of the token
+	Raw    string // the token's text in the document (such as `"a\nb"` for a string)
+	Depth  int    // the number of objects and arrays that contain the token
+
+	// Path is the key path of the value. For a property name, it is the path of
+	// the property's value, and for the beginning and end of an object or array,
+	// it is the path of the object or array.
+	Path Path
+
+	value string // the decoded string or number
+}
+
+// StringValue returns the value of a TokenString or the name of a TokenProperty.
+func (t Token) StringValue() string {
+	if t.Kind != TokenString && t.Kind != TokenProperty {
+		return ""
+	}
+	return t.value
+}
+
+// Number returns the value of a TokenNumber.
+func (t Token) Number() json.Number {
+	if t.Kind != TokenNumber {
+		return ""
+	}
+	return json.Number(t.value)
+}
+
+// Bool returns the value of a TokenBool.
+func (t Token) Bool() bool {
+	return t.Kind == TokenBool && len(t.Raw) > 0 && t.Raw[0] == 't'
+}
+
+// A Reader reads the tokens of a JSON document one at a time, without building a
+// parse tree or converting values to interface{}. Reading a token does not
+// allocate, except to decode a string with escape sequences.
+//
+// Unlike Walk, a Reader does not recover from syntax errors: it stops at the first
+// one, which Err reports.
+type Reader struct {
+	scanner *Scanner
+	options ParseOptions
+	text    string
+
+	// the character and byte offsets of a position in the text, to convert
+	// character offsets to byte offsets
+	charOffset, byteOffset int
+
+	state   readerState
+	objects []bool // for each enclosing object or array, whether it is an object
+	path    Path
+	err     *ParseError
+	eof     Token
+}
+
+// A readerState is what the Reader expects next.
+type readerState int
+
+const (
+	expectValue              readerState = iota // a value (such as after a colon)
+	expectFirstValueOrEnd                       // the first value of an array, or its end
+	expectValueOrEnd                            // a value after a comma in an array, or its end (with a trailing comma)
+	expectFirstPropertyOrEnd                    // the first property of an object, or its end
+	expectPropertyOrEnd                         // a property after a comma, or the end of the object (with a trailing comma)
+	expectColon                                 // the colon after a property name
+	expectCommaOrEnd                            // a comma or the end of the object or array
+	expectEOF                                   // the end of the document
+	done
+)
+
+// NewReader returns a Reader for the JSON document text. Like a Scanner, it
+// converts the text to characters once, up front; the tokens' Raw fields are
+// substrings of the text.
+func NewReader(text string, options ParseOptions) *Reader {
+	return &Reader{
+		scanner: NewScanner(text, ScanOptions{Trivia: true}),
+		options: options,
+		text:    text,
+		path:    Path{},
+	}
+}
+
+// Next returns the next token of the document. At the end of the document, or at
+// the first syntax error, it returns a token of kind TokenEOF.
+func (r *Reader) Next() Token {
+	for r.state != done {
+		kind := r.scan()
+		if r.state == done {
+			break // a scan error
+		}
+
+		switch r.state {
+		case expectValue, expectFirstValueOrEnd, expectValueOrEnd:
+			if kind == CloseBracketToken && (r.state == expectFirstValueOrEnd || r.state == expectValueOrEnd && r.options.TrailingCommas) {
+				return r.end(TokenArrayEnd)
+			}
+			if kind == EOF && len(r.objects) == 0 {
+				r.state = done // an empty document
+				return r.endOfInput()
+			}
+			if kind == EOF && (r.state == expectFirstValueOrEnd || r.state == expectValueOrEnd && r.options.TrailingCommas) {
+				r.fail(CloseBracketExpected)
+				break
+			}
+			return r.value(kind)
+
+		case expectFirstPropertyOrEnd, expectPropertyOrEnd:
+			if kind == CloseBraceToken && (r.state == expectFirstPropertyOrEnd || r.options.TrailingCommas) {
+				return r.end(TokenObjectEnd)
+			}
+			if kind == EOF && (r.state == expectFirstPropertyOrEnd || r.options.TrailingCommas) {
+				r.fail(CloseBraceExpected)
+				break
+			}
+			if kind != StringLiteral {
+				r.fail(PropertyNameExpected)
+				break
+			}
+			r.path[len(r.path)-1].Property = r.stringValue()
+			r.state = expectColon
+			return r.token(TokenProperty, r.path[len(r.path)-1].Property)
+
+		case expectColon:
+			if kind != ColonToken {
+				r.fail(ColonExpected)
+				break
+			}
+			r.state = expectValue
+
+		case expectCommaOrEnd:
+			object := r.objects[len(r.objects)-1]
+			switch {
+			case kind == CommaToken && object:
+				r.state = expectPropertyOrEnd
+			case kind == CommaToken:
+				r.path[len(r.path)-1].Index++
+				r.state = expectValueOrEnd
+			case kind == CloseBraceToken && object:
+				return r.end(TokenObjectEnd)
+			case kind == CloseBracketToken && !object:
+				return r.end(TokenArrayEnd)
+			case kind == EOF && object:
+				r.fail(CloseBraceExpected)
+			case kind == EOF:
+				r.fail(CloseBracketExpected)
+			default:
+				r.fail(CommaExpected)
+			}
+
+		case expectEOF:
+			if kind != EOF {
+				r.fail(EndOfFileExpected)
+				break
+			}
+			r.state = done
+			return r.endOfInput()
+		}
+	}
+	return r.eof
+}
+
+// Err returns the syntax error at which the Reader stopped, or nil.
+func (r *Reader) Err() error {
+	if r.err == nil {
+		return nil
+	}
+	return r.err
+}
+
+// scan scans the next token other than trivia, and checks it for errors.
+func (r *Reader) scan() SyntaxKind {
+	for {
+		kind := r.scanner.Scan()
+		switch r.scanner.Err() {
+		case None:
+		case UnexpectedEndOfComment:
+			if r.options.Comments {
+				r.fail(ParseErrorUnexpectedEndOfComment)
+				return kind
+			}
+		case UnexpectedEndOfString:
+			r.fail(ParseErrorUnexpectedEndOfString)
+			return kind
+		case UnexpectedEndOfNumber:
+			r.fail(ParseErrorUnexpectedEndOfNumber)
+			return kind
+		case InvalidUnicode:
+			r.fail(ParseErrorInvalidUnicode)
+			return kind
+		case InvalidEscapeCharacter:
+			r.fail(ParseErrorInvalidEscapeCharacter)
+			return kind
+		case InvalidCharacter:
+			r.fail(ParseErrorInvalidCharacter)
+			return kind
+		default:
+			r.fail(InvalidScanErrorCode)
+			return kind
+		}
+
+		switch kind {
+		case LineCommentTrivia, BlockCommentTrivia:
+			if !r.options.Comments {
+				r.fail(InvalidCommentToken)
+				return kind
+			}
+		case Unknown:
+			r.fail(InvalidSymbol)
+			return kind
+		case Trivia, LineBreakTrivia:
+		default:
+			return kind
+		}
+	}
+}
+
+// value returns the token for the value that starts with the last-scanned token.
+func (r *Reader) value(kind SyntaxKind) Token {
+	var t Token
+	switch kind {
+	case OpenBraceToken:
+		t = r.token(TokenObjectBegin, "")
+		r.objects = append(r.objects, true)
+		r.path = append(r.path, Segment{IsProperty: true})
+		r.state = expectFirstPropertyOrEnd
+		return t
+	case OpenBracketToken:
+		t = r.token(TokenArrayBegin, "")
+		r.objects = append(r.objects, false)
+		r.path = append(r.path, Segment{})
+		r.state = expectFirstValueOrEnd
+		return t
+	case StringLiteral:
+		t = r.token(TokenString, r.stringValue())
+	case NumericLiteral:
+		t = r.token(TokenNumber, "")
+		t.value = t.Raw // incomplete numbers (such as `1.`) are scan errors
+		if _, err := strconv.ParseFloat(t.value, 64); err != nil {
+			r.fail(InvalidNumberFormat)
+			return r.eof
+		}
+	case TrueKeyword, FalseKeyword:
+		t = r.token(TokenBool, "")
+	case NullKeyword:
+		t = r.token(TokenNull, "")
+	default:
+		r.fail(ValueExpected)
+		return r.eof
+	}
+	r.afterValue()
+	return t
+}
+
+// end returns the token for the end of the current object or array.
+func (r *Reader) end(kind TokenKind) Token {
+	r.objects = r.objects[:len(r.objects)-1]
+	r.path = r.path[:len(r.path)-1]
+	t := r.token(kind, "")
+	r.afterValue()
+	return t
+}
+
+func (r *Reader) afterValue() {
+	if len(r.objects) == 0 {
+		r.state = expectEOF
+	} else {
+		r.state = expectCommaOrEnd
+	}
+}
+
+// token returns a token of the kind for the last-scanned token.
+func (r *Reader) token(kind TokenKind, value string) Token {
+	offset, length := r.scanner.TokenOffset(), r.scanner.TokenLength()
+	start := r.byteOffsetOf(offset)
+	return Token{
+		Kind:   kind,
+		Offset: offset,
+		Length: length,
+		Raw:    r.text[start:r.byteOffsetOf(offset+length)],
+		Depth:  len(r.objects),
+		Path:   r.path,
+		value:  value,
+	}
+}
+
+// stringValue returns the value of the last-scanned string token.
+func (r *Reader) stringValue() string {
+	start := r.byteOffsetOf(r.scanner.TokenOffset())
+	raw := r.text[start:r.byteOffsetOf(r.scanner.Pos())]
+	if len(raw) >= 2 && !strings.Contains(raw, `\`) {
+		return raw[1 : len(raw)-1] // no escapes, so share the text
+	}
+	return r.scanner.Value()
+}
+
+// byteOffsetOf returns the byte offset of the character offset in the text. It
+// moves from the offset of the previous call, so it is fast for nearby offsets.
+func (r *Reader) byteOffsetOf(offset int) int {
+	for r.charOffset < offset && r.byteOffset < len(r.text) {
+		_, size := utf8.DecodeRuneInString(r.text[r.byteOffset:])
+		r.byteOffset += size
+		r.charOffset++
+	}
+	for r.charOffset > offset {
+		_, size := utf8.DecodeLastRuneInString(r.text[:r.byteOffset])
+		r.byteOffset -= size
+		r.charOffset--
+	}
+	return r.byteOffset
+}
+
+// fail stops the Reader with a syntax error at the last-scanned token.
+func (r *Reader) fail(code ParseErrorCode) {
+	r.err = &ParseError{Code: code, Offset: r.scanner.TokenOffset(), Length: r.scanner.TokenLength()}
+	r.state = done
+	r.endOfInput()
+}
+
+// endOfInput returns a TokenEOF at the last-scanned token.
+func (r *Reader) endOfInput() Token {
+	r.eof = Token{Kind: TokenEOF, Offset: r.scanner.TokenOffset(), Depth: len(r.objects), Path: r.path}
+	return r.eof
+}
@@ -0,0 +1,212 @@
+package jsonx
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestReader(t *testing.T) {
+	tests := map[string]struct {
+		input   string
+		options ParseOptions
+		want    []string // kind, offset, length, depth, path and value of each token
+		wantErr *ParseError
+	}{
+		"empty": {
+			input: "  ",
+			want:  []string{`TokenEOF 2+0 0 []`},
+		},
+		"literals": {
+			input: `[1.5, "x", true, false, null]`,
+			want: []string{
+				`TokenArrayBegin 0+1 0 []`,
+				`TokenNumber 1+3 1 [0] 1.5`,
+				`TokenString 6+3 1 [1] x`,
+				`TokenBool 11+4 1 [2] true`,
+				`TokenBool 17+5 1 [3] false`,
+				`TokenNull 24+4 1 [4]`,
+				`TokenArrayEnd 28+1 0 []`,
+				`TokenEOF 29+0 0 []`,
+			},
+		},
+		"objects": {
+			input:   "// comment\n{\"a\": {\"b\": [{}]}, \"c\\n\": \"d\\u00e9\",}",
+			options: ParseOptions{Comments: true, TrailingCommas: true},
+			want: []string{
+				`TokenObjectBegin 11+1 0 []`,
+				`TokenProperty 12+3 1 ["a"] a`,
+				`TokenObjectBegin 17+1 1 ["a"]`,
+				`TokenProperty 18+3 2 ["a","b"] b`,
+				`TokenArrayBegin 23+1 2 ["a","b"]`,
+				`TokenObjectBegin 24+1 3 ["a","b",0]`,
+				`TokenObjectEnd 25+1 3 ["a","b",0]`,
+				`TokenArrayEnd 26+1 2 ["a","b"]`,
+				`TokenObjectEnd 27+1 1 ["a"]`,
+				`TokenProperty 30+5 1 ["c\n"] c` + "\n",
+				`TokenString 37+9 1 ["c\n"] dé`,
+				`TokenObjectEnd 47+1 0 []`,
+				`TokenEOF 48+0 0 []`,
+			},
+		},
+		"non-ASCII characters": {
+			input: `{"é": "ü"}`,
+			want: []string{
+				`TokenObjectBegin 0+1 0 []`,
+				`TokenProperty 1+3 1 ["é"] é`,
+				`TokenString 6+3 1 ["é"] ü`,
+				`TokenObjectEnd 9+1 0 []`,
+				`TokenEOF 10+0 0 []`,
+			},
+		},
+		"comment not allowed": {
+			input:   "[1, /**/ 2]",
+			want:    []string{`TokenArrayBegin 0+1 0 []`, `TokenNumber 1+1 1 [0] 1`, `TokenEOF 4+0 1 [1]`},
+			wantErr: &ParseError{Code: InvalidCommentToken, Offset: 4, Length: 4},
+		},
+		"trailing comma not allowed": {
+			input:   `{"a": 1,}`,
+			want:    []string{`TokenObjectBegin 0+1 0 []`, `TokenProperty 1+3 1 ["a"] a`, `TokenNumber 6+1 1 ["a"] 1`, `TokenEOF 8+0 1 ["a"]`},
+			wantErr: &ParseError{Code: PropertyNameExpected, Offset: 8, Length: 1},
+		},
+		"missing comma": {
+			input:   `[1 2]`,
+			want:    []string{`TokenArrayBegin 0+1 0 []`, `TokenNumber 1+1 1 [0] 1`, `TokenEOF 3+0 1 [0]`},
+			wantErr: &ParseError{Code: CommaExpected, Offset: 3, Length: 1},
+		},
+		"missing colon": {
+			input:   `{"a" 1}`,
+			want:    []string{`TokenObjectBegin 0+1 0 []`, `TokenProperty 1+3 1 ["a"] a`, `TokenEOF 5+0 1 ["a"]`},
+			wantErr: &ParseError{Code: ColonExpected, Offset: 5, Length: 1},
+		},
+		"unclosed array": {
+			input:   `[`,
+			want:    []string{`TokenArrayBegin 0+1 0 []`, `TokenEOF 1+0 1 [0]`},
+			wantErr: &ParseError{Code: CloseBracketExpected, Offset: 1},
+		},
+		"invalid number": {
+			input:   `1e999`,
+			want:    []string{`TokenEOF 0+0 0 []`},
+			wantErr: &ParseError{Code: InvalidNumberFormat, Offset: 0, Length: 5},
+		},
+		"unterminated string": {
+			input:   `["a`,
+			want:    []string{`TokenArrayBegin 0+1 0 []`, `TokenEOF 1+0 1 [0]`},
+			wantErr: &ParseError{Code: ParseErrorUnexpectedEndOfString, Offset: 1, Length: 2},
+		},
+		"extra value": {
+			input:   `1 2`,
+			want:    []string{`TokenNumber 0+1 0 [] 1`, `TokenEOF 2+0 0 []`},
+			wantErr: &ParseError{Code: EndOfFileExpected, Offset: 2, Length: 1},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			r := NewReader(test.input, test.options)
+			var got []string
+			for {
+				token := r.Next()
+				if want := []rune(test.input)[token.Offset : token.Offset+token.Length]; token.Raw != string(want) {
+					t.Errorf("got raw %q, want %q", token.Raw, string(want))
+				}
+				s := fmt.Sprintf("%v %d+%d %d %s", token.Kind, token.Offset, token.Length, token.Depth, token.Path)
+				switch token.Kind {
+				case TokenProperty, TokenString:
+					s += " " + token.StringValue()
+				case TokenNumber:
+					s += " " + string(token.Number())
+				case TokenBool:
+					s += fmt.Sprintf(" %v", token.Bool())
+				}
+				got = append(got, s)
+				if token.Kind == TokenEOF {
+					break
+				}
+			}
+			if strings.Join(got, "\n") != strings.Join(test.want, "\n") {
+				t.Errorf("got tokens\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(test.want, "\n"))
+			}
+			var gotErr *ParseError
+			if err := r.Err(); err != nil {
+				gotErr = err.(*ParseError)
+			}
+			if fmt.Sprint(gotErr) != fmt.Sprint(test.wantErr) {
+				t.Errorf("got error %v, want %v", gotErr, test.wantErr)
+			}
+			if token := r.Next(); token.Kind != TokenEOF {
+				t.Errorf("got %v after the end, want TokenEOF", token.Kind)
+			}
+		})
+	}
+}
+
+func TestReader_allocations(t *testing.T) {
+	// Reading each token must not allocate, so the allocations must not depend on
+	// the length of the document.
+	allocs := func(text string) float64 {
+		return testing.AllocsPerRun(10, func() {
+			r := NewReader(text, ParseOptions{Comments: true})
+			for r.Next().Kind != TokenEOF {
+			}
+		})
+	}
+	const element = `{"name": "x", "size": 1.5, "tags": [true, null]}, // comment` + "\n"
+	short := allocs("[" + element + "{}]")
+	long := allocs("[" + strings.Repeat(element, 100) + "{}]")
+	if long != short {
+		t.Errorf("got %v allocations for 101 elements, %v for 2 elements, want the same", long, short)
+	}
+}
+
+func TestReader_matchesParse(t *testing.T) {
+	const input = `{"a": [1, 2.5e3, {"b": "\"\té"}], "c": {}, "d": [], "e": null, "f": false}`
+	want, errors := Parse(input, ParseOptions{})
+	if len(errors) > 0 {
+		t.Fatal(errors)
+	}
+
+	// Rebuild the document from the tokens.
+	var buf strings.Builder
+	r := NewReader(input, ParseOptions{})
+	needsComma := false
+	for token := r.Next(); token.Kind != TokenEOF; token = r.Next() {
+		if needsComma && token.Kind != TokenObjectEnd && token.Kind != TokenArrayEnd {
+			buf.WriteByte(',')
+		}
+		needsComma = token.Kind != TokenObjectBegin && token.Kind != TokenArrayBegin && token.Kind != TokenProperty
+		switch token.Kind {
+		case TokenObjectBegin:
+			buf.WriteByte('{')
+		case TokenObjectEnd:
+			buf.WriteByte('}')
+		case TokenArrayBegin:
+			buf.WriteByte('[')
+		case TokenArrayEnd:
+			buf.WriteByte(']')
+		case TokenProperty, TokenString:
+			data, _ := json.Marshal(token.StringValue())
+			buf.Write(data)
+			if token.Kind == TokenProperty {
+				buf.WriteByte(':')
+			}
+		case TokenNumber:
+			buf.WriteString(string(token.Number()))
+		default:
+			buf.WriteString(token.Raw)
+		}
+	}
+	if r.Err() != nil {
+		t.Fatal(r.Err())
+	}
+	var got, wantValue interface{}
+	if err := json.Unmarshal([]byte(buf.String()), &got); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(want, &wantValue); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(got) != fmt.Sprint(wantValue) {
+		t.Errorf("got %v, want %v", got, wantValue)
+	}
+}
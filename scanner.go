@@ -5,6 +5,11 @@
 
 package jsonx
 
+import (
+	"unicode"
+	"unicode/utf16"
+)
+
 // ScanOptions specifies options for NewScanner.
 type ScanOptions struct {
 	Trivia bool // scan and emit whitespace and comment elements (false to ignore)
@@ -93,7 +98,7 @@ func (s *Scanner) Scan() SyntaxKind {
 func (s *Scanner) scanHexDigits(count int, exact bool) rune {
 	digits := 0
 	var value rune
-	for digits < count || !exact {
+	for (digits < count || !exact) && s.pos < s.len {
 		ch := s.text[s.pos]
 		if ch >= charCode0 && ch <= charCode9 {
 			value = rune(value*16) + ch - charCode0
@@ -138,7 +143,7 @@ func (s *Scanner) scanNumber() []rune {
 	end := s.pos
 	if s.pos < len(s.text) && (s.text[s.pos] == charCodeE || s.text[s.pos] == charCodeLowerE) {
 		s.pos++
-		if s.pos < len(s.text) && s.text[s.pos] == charCodePlus || s.text[s.pos] == charCodeMinus {
+		if s.pos < len(s.text) && (s.text[s.pos] == charCodePlus || s.text[s.pos] == charCodeMinus) {
 			s.pos++
 		}
 		if s.pos < len(s.text) && isDigit(s.text[s.pos]) {
@@ -166,7 +171,11 @@ func (s *Scanner) scanString() []rune {
 		}
 		ch := s.text[s.pos]
 		if ch == charCodeDoubleQuote {
-			result = append(result, s.text[start:s.pos]...)
+			if result == nil {
+				result = s.text[start:s.pos] // no escapes, so share the text
+			} else {
+				result = append(result, s.text[start:s.pos]...)
+			}
 			s.pos++
 			break
 		}
@@ -199,6 +208,16 @@ func (s *Scanner) scanString() []rune {
 			case charCodeLowerU:
 				ch := s.scanHexDigits(4, true)
 				if ch >= 0 {
+					if utf16.IsSurrogate(ch) && s.pos+1 < s.len && s.text[s.pos] == charCodeBackslash && s.text[s.pos+1] == charCodeLowerU {
+						// combine a UTF-16 surrogate pair (e.g., `\ud83d\ude00`)
+						pos := s.pos
+						s.pos += 2
+						if r := utf16.DecodeRune(ch, s.scanHexDigits(4, true)); r != unicode.ReplacementChar {
+							ch = r
+						} else {
+							s.pos = pos
+						}
+					}
 					result = append(result, ch)
 				} else {
 					s.err = InvalidUnicode
@@ -243,7 +262,6 @@ func (s *Scanner) scanNext() SyntaxKind {
 	if isWhiteSpace(code) {
 		for {
 			s.pos++
-			s.value = append(s.value, code)
 			if s.pos >= s.len {
 				break
 			}
@@ -252,6 +270,7 @@ func (s *Scanner) scanNext() SyntaxKind {
 				break
 			}
 		}
+		s.value = s.text[s.tokenOffset:s.pos]
 
 		s.token = Trivia
 		return s.token
@@ -260,11 +279,10 @@ func (s *Scanner) scanNext() SyntaxKind {
 	// trivia: newlines
 	if isLineBreak(code) {
 		s.pos++
-		s.value = append(s.value, code)
 		if code == charCodeCarriageReturn && s.pos < s.len && s.text[s.pos] == charCodeLineFeed {
 			s.pos++
-			s.value = append(s.value, '\n')
 		}
+		s.value = s.text[s.tokenOffset:s.pos]
 		s.token = LineBreakTrivia
 		return s.token
 	}
@@ -343,7 +361,7 @@ func (s *Scanner) scanNext() SyntaxKind {
 			}
 
 			if !commentClosed {
-				s.pos++
+				s.pos = s.len
 				s.err = UnexpectedEndOfComment
 			}
 
@@ -362,8 +380,8 @@ func (s *Scanner) scanNext() SyntaxKind {
 
 	// numbers
 	case charCodeMinus:
-		s.value = append(s.value, code)
 		s.pos++
+		s.value = s.text[s.tokenOffset:s.pos]
 		if s.pos == s.len || !isDigit(s.text[s.pos]) {
 			s.token = Unknown
 			return s.token
@@ -374,7 +392,8 @@ func (s *Scanner) scanNext() SyntaxKind {
 	// we fall through to proceed with scanning
 	// numbers
 	case charCode0, charCode1, charCode2, charCode3, charCode4, charCode5, charCode6, charCode7, charCode8, charCode9:
-		s.value = append(s.value, s.scanNumber()...)
+		number := s.scanNumber()
+		s.value = s.text[s.tokenOffset : s.tokenOffset+len(s.value)+len(number)]
 		s.token = NumericLiteral
 		return s.token
 	// literals and unknown symbols
@@ -412,6 +431,49 @@ func (s *Scanner) scanNext() SyntaxKind {
 	}
 }
 
+// skipContainer moves the position to the close brace or bracket that matches an
+// open brace or bracket before the position (or to the end of the text), so that
+// the next token is the close brace or bracket. It only looks for braces and
+// brackets outside of strings and comments, without scanning the tokens.
+func (s *Scanner) skipContainer() {
+	depth := 1
+	for ; s.pos < s.len; s.pos++ {
+		switch s.text[s.pos] {
+		case charCodeOpenBrace, charCodeOpenBracket:
+			depth++
+		case charCodeCloseBrace, charCodeCloseBracket:
+			if depth--; depth == 0 {
+				return
+			}
+		case charCodeDoubleQuote:
+			// Skip to the closing quote (or the end of the line, where an
+			// unterminated string ends, even after a backslash).
+			for s.pos++; s.pos < s.len && s.text[s.pos] != charCodeDoubleQuote && !isLineBreak(s.text[s.pos]); s.pos++ {
+				if s.text[s.pos] == charCodeBackslash && s.pos+1 < s.len && !isLineBreak(s.text[s.pos+1]) {
+					s.pos++
+				}
+			}
+		case charCodeSlash:
+			if s.pos+1 >= s.len {
+				continue
+			}
+			switch s.text[s.pos+1] {
+			case charCodeSlash:
+				for s.pos < s.len && !isLineBreak(s.text[s.pos]) {
+					s.pos++
+				}
+			case charCodeAsterisk:
+				s.pos += 2
+				for s.pos < s.len && !(s.text[s.pos] == charCodeAsterisk && s.pos+1 < s.len && s.text[s.pos+1] == charCodeSlash) {
+					s.pos++
+				}
+				s.pos++ // to the slash
+			}
+		}
+	}
+	s.pos = s.len
+}
+
 func (s *Scanner) scanNextNonTrivia() SyntaxKind {
 	var result SyntaxKind
 	for {
@@ -133,3 +133,56 @@ func TestScannerErrors(t *testing.T) {
 		}
 	}
 }
+
+func FuzzScanner(f *testing.F) {
+	for _, input := range fuzzSeedCorpus(f) {
+		f.Add(input, true)
+		f.Add(input, false)
+	}
+	f.Fuzz(func(t *testing.T, input string, trivia bool) {
+		n := len([]rune(input))
+		scanner := NewScanner(input, ScanOptions{Trivia: trivia})
+		pos := 0
+		for i := 0; ; i++ {
+			if i > n {
+				t.Fatalf("%q: scanner did not reach EOF after %d tokens", input, i)
+			}
+			kind := scanner.Scan()
+			offset, length := scanner.TokenOffset(), scanner.TokenLength()
+			if offset < pos || length < 0 || offset+length > n {
+				t.Fatalf("%q: token %s at offset %d, length %d out of bounds (previous token ended at %d)", input, kind, offset, length, pos)
+			}
+			if kind == EOF {
+				if offset != n {
+					t.Fatalf("%q: got EOF at offset %d, want %d", input, offset, n)
+				}
+				break
+			}
+			if length == 0 {
+				t.Fatalf("%q: got empty %s token at offset %d", input, kind, offset)
+			}
+			pos = offset + length
+		}
+	})
+}
+
+func TestScanner_skipContainer(t *testing.T) {
+	tests := map[string]int{ // the input (after an open brace or bracket) and the position of its end
+		`}`:                        0,
+		`"a": [1, {}], "b": 2}, 3`: 20,
+		`"}]\"}", 1]`:              10,
+		"// }\n]":                  5,
+		`/* ] */]`:                 7,
+		"\"a\n]":                   3,
+		"\"a\\\n]":                 4,
+		`[{}`:                      3,
+		`/* ]`:                     4,
+	}
+	for input, want := range tests {
+		s := NewScanner(input, ScanOptions{})
+		s.skipContainer()
+		if s.Pos() != want {
+			t.Errorf("%q: got position %d, want %d", input, s.Pos(), want)
+		}
+	}
+}
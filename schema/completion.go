@@ -0,0 +1,408 @@
+// This file was ported from https://github.com/microsoft/vscode-json-languageservice/blob/main/src/services/jsonCompletion.ts,
+// which is licensed as follows:
+//
+// Copyright (c) Microsoft Corporation. All rights reserved. Licensed under the MIT License.
+
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/sourcegraph/jsonx"
+)
+
+// CompletionKind is the kind of a completion item.
+type CompletionKind int
+
+const (
+	PropertyCompletion CompletionKind = iota // an object property
+	ValueCompletion                          // a value
+)
+
+// A CompletionItem is a suggestion to insert a property or value at a position in a
+// JSON document.
+type CompletionItem struct {
+	Label               string         // the text to show in the list of suggestions
+	Kind                CompletionKind // whether the item is a property or a value
+	Description         string         // the description from the schema, in plain text
+	MarkdownDescription string         // the description from the schema, in Markdown
+
+	// InsertText is the text to replace the Range with, in LSP snippet syntax (with
+	// tab stops and placeholders such as $1 and ${1:value}). It includes the comma
+	// to separate the inserted property or value from the next one, if needed.
+	InsertText string
+	Range      jsonx.Range // the range of the text to replace
+}
+
+// Complete returns the completion items suggested by the schema at the character
+// offset in the JSON document text.
+//
+// Inside an object, it suggests the properties of the schema that are not already
+// present, with a placeholder value from the property schema (its default snippet,
+// single enum value, const, default or first example, or an empty value of its
+// type). At a value, it suggests the enum values, const, default, examples and
+// default snippets of the schema, and values of its types (such as true and false
+// for booleans).
+//
+// Source: https://github.com/microsoft/vscode-json-languageservice/blob/main/src/services/jsonCompletion.ts
+func Complete(text string, offset int, s *Schema) []CompletionItem {
+	chars := []rune(text)
+	if offset < 0 || offset > len(chars) {
+		return nil
+	}
+	root, _ := jsonx.ParseTree(text, parseOptions)
+	node := jsonx.FindNodeAtOffset(root, offset, true)
+	if node != nil && offset == node.Offset+node.Length && offset > 0 {
+		if ch := chars[offset-1]; node.Type == jsonx.Object && ch == '}' || node.Type == jsonx.Array && ch == ']' {
+			node = node.Parent // after the object or array
+		}
+	}
+
+	var overwrite jsonx.Range
+	if node != nil && isLiteral(node) {
+		overwrite = jsonx.Range{Offset: node.Offset, Length: node.Length}
+	} else {
+		start := offset
+		for start > 0 && !strings.ContainsRune(" \t\n\r\v\":{[,]}", chars[start-1]) {
+			start--
+		}
+		if start > 0 && chars[start-1] == '"' {
+			start--
+		}
+		overwrite = jsonx.Range{Offset: start, Length: offset - start}
+	}
+	c := &completer{text: text, overwrite: overwrite, seen: map[string]bool{}}
+
+	// Suggest properties inside an object, and at a property's key.
+	addValue := true
+	var currentProperty *jsonx.Node
+	if node != nil && node.Type == jsonx.String && node.Parent != nil && node.Parent.Type == jsonx.Property && node.Parent.Children[0] == node {
+		currentProperty = node.Parent
+		addValue = len(currentProperty.Children) < 2
+		node = currentProperty.Parent
+	}
+	if node != nil && node.Type == jsonx.Object {
+		if node.Offset == offset {
+			return nil // before the opening brace
+		}
+		present := map[string]bool{}
+		for _, property := range node.Children {
+			if property != currentProperty {
+				present[property.Children[0].Value.(string)] = true
+			}
+		}
+		var separatorAfter string
+		if addValue {
+			separatorAfter = c.separatorAfter(overwrite.Offset + overwrite.Length)
+		}
+		for _, sub := range s.schemasAt(root, jsonx.NodePath(node)) {
+			for _, name := range sortedKeys(sub.Properties) {
+				if present[name] || sub.Properties[name].isFalse {
+					continue
+				}
+				propertySchemas := applicable([]*Schema{sub.Properties[name]}, nil)
+				description, markdownDescription := describe(propertySchemas)
+				c.add(CompletionItem{
+					Label:               name,
+					Kind:                PropertyCompletion,
+					Description:         description,
+					MarkdownDescription: markdownDescription,
+					InsertText:          propertyInsertText(name, propertySchemas, addValue, separatorAfter),
+				})
+			}
+		}
+		return c.items
+	}
+
+	// Suggest values for the value of a property or an array element, or for the
+	// root value.
+	offsetForSeparator := offset
+	if node != nil && isLiteral(node) {
+		offsetForSeparator = node.Offset + node.Length
+		node = node.Parent
+	}
+	var schemas []*Schema
+	switch {
+	case node == nil:
+		schemas = applicable([]*Schema{s}, nil)
+	case node.Type == jsonx.Property:
+		if offset <= node.ColumnOffset {
+			return nil // before the colon
+		}
+		if len(node.Children) >= 2 {
+			if value := node.Children[1]; offset >= value.Offset+value.Length {
+				return nil // after the value
+			}
+		}
+		segment := jsonx.Segment{IsProperty: true, Property: node.Children[0].Value.(string)}
+		schemas = applicable(childSchemas(s.schemasAt(root, jsonx.NodePath(node.Parent)), segment), nil)
+	case node.Type == jsonx.Array:
+		segment := jsonx.Segment{Index: c.itemIndex(node, offset)}
+		schemas = applicable(childSchemas(s.schemasAt(root, jsonx.NodePath(node)), segment), nil)
+	default:
+		return nil
+	}
+	separatorAfter := c.separatorAfter(offsetForSeparator)
+	for _, sub := range schemas {
+		c.addValues(sub, separatorAfter)
+	}
+	return c.items
+}
+
+// describe returns the first description and Markdown description of the
+// schemas.
+func describe(schemas []*Schema) (description, markdownDescription string) {
+	for _, s := range schemas {
+		if description == "" {
+			description = s.Description
+		}
+		if markdownDescription == "" {
+			markdownDescription = s.MarkdownDescription
+		}
+	}
+	return description, markdownDescription
+}
+
+func isLiteral(node *jsonx.Node) bool {
+	switch node.Type {
+	case jsonx.String, jsonx.Number, jsonx.Boolean, jsonx.Null:
+		return true
+	}
+	return false
+}
+
+// A completer collects completion items.
+type completer struct {
+	text      string
+	overwrite jsonx.Range // the range to replace with the items
+	items     []CompletionItem
+	seen      map[string]bool // the labels of the items
+}
+
+// add adds the item, unless there is already an item with the same label.
+func (c *completer) add(item CompletionItem) {
+	if c.seen[item.Label] {
+		return
+	}
+	c.seen[item.Label] = true
+	item.Range = c.overwrite
+	c.items = append(c.items, item)
+}
+
+// addValues adds the values suggested by the schema.
+func (c *completer) addValues(s *Schema, separatorAfter string) {
+	if s.isFalse {
+		return
+	}
+	addValue := func(value json.RawMessage, description, markdownDescription string) {
+		c.add(CompletionItem{
+			Label:               compact(value),
+			Kind:                ValueCompletion,
+			Description:         description,
+			MarkdownDescription: markdownDescription,
+			InsertText:          escapeSnippet(indent(value)) + separatorAfter,
+		})
+	}
+
+	if len(s.Default) > 0 {
+		addValue(s.Default, s.Description, s.MarkdownDescription)
+	}
+	for _, example := range s.Examples {
+		addValue(example, "", "")
+	}
+	for _, snippet := range s.DefaultSnippets {
+		label, insertText := snippet.Label, snippet.BodyText
+		if len(snippet.Body) > 0 {
+			insertText = snippetBody(snippet.Body)
+			if label == "" {
+				label = compact(snippet.Body)
+			}
+		}
+		if label == "" {
+			label = insertText
+		}
+		c.add(CompletionItem{
+			Label:               label,
+			Kind:                ValueCompletion,
+			Description:         snippet.Description,
+			MarkdownDescription: snippet.MarkdownDescription,
+			InsertText:          insertText + separatorAfter,
+		})
+	}
+	for i, value := range s.Enum {
+		var description, markdownDescription string
+		if i < len(s.EnumDescriptions) {
+			description = s.EnumDescriptions[i]
+		}
+		if i < len(s.MarkdownEnumDescriptions) {
+			markdownDescription = s.MarkdownEnumDescriptions[i]
+		}
+		if description == "" && markdownDescription == "" {
+			description, markdownDescription = s.Description, s.MarkdownDescription
+		}
+		addValue(value, description, markdownDescription)
+	}
+	if len(s.Const) > 0 {
+		addValue(s.Const, s.Description, s.MarkdownDescription)
+	}
+
+	for _, t := range s.Type {
+		switch t {
+		case "boolean":
+			addValue(json.RawMessage("true"), "", "")
+			addValue(json.RawMessage("false"), "", "")
+		case "null":
+			addValue(json.RawMessage("null"), "", "")
+		case "object":
+			c.add(CompletionItem{Label: "{}", Kind: ValueCompletion, InsertText: "{$1}" + separatorAfter})
+		case "array":
+			c.add(CompletionItem{Label: "[]", Kind: ValueCompletion, InsertText: "[$1]" + separatorAfter})
+		}
+	}
+}
+
+// separatorAfter returns the separator to insert after a completion ending at the
+// offset: a comma, unless the next token ends the object or array or is a comma.
+func (c *completer) separatorAfter(offset int) string {
+	scanner := jsonx.NewScanner(c.text, jsonx.ScanOptions{Trivia: false})
+	scanner.SetPosition(offset)
+	switch scanner.Scan() {
+	case jsonx.CommaToken, jsonx.CloseBraceToken, jsonx.CloseBracketToken, jsonx.EOF:
+		return ""
+	}
+	return ","
+}
+
+// itemIndex returns the index of the element of the array at the offset.
+func (c *completer) itemIndex(array *jsonx.Node, offset int) int {
+	for i := len(array.Children) - 1; i >= 0; i-- {
+		child := array.Children[i]
+		if end := child.Offset + child.Length; offset > end {
+			scanner := jsonx.NewScanner(c.text, jsonx.ScanOptions{Trivia: false})
+			scanner.SetPosition(end)
+			if scanner.Scan() == jsonx.CommaToken && offset >= scanner.TokenOffset()+scanner.TokenLength() {
+				return i + 1
+			}
+			return i
+		} else if offset >= child.Offset {
+			return i
+		}
+	}
+	return 0
+}
+
+// propertyInsertText returns the snippet to insert for the property with the
+// schemas (see applicable), with a placeholder for its value if addValue.
+func propertyInsertText(name string, schemas []*Schema, addValue bool, separatorAfter string) string {
+	key, _ := json.Marshal(name)
+	insertText := escapeSnippet(string(key))
+	if !addValue {
+		return insertText
+	}
+
+	var value, t string
+	proposals := 0
+	for _, s := range schemas {
+		proposals += len(s.DefaultSnippets) + len(s.Enum) + len(s.Examples)
+		if value == "" && len(s.DefaultSnippets) == 1 && len(s.DefaultSnippets[0].Body) > 0 {
+			value = snippetBody(s.DefaultSnippets[0].Body)
+		}
+		if value == "" && len(s.Enum) == 1 {
+			value = guessedValue(s.Enum[0])
+		}
+		if len(s.Const) > 0 {
+			proposals++
+			if value == "" {
+				value = guessedValue(s.Const)
+			}
+		}
+		if len(s.Default) > 0 {
+			proposals++
+			if value == "" {
+				value = guessedValue(s.Default)
+			}
+		}
+		if value == "" && len(s.Examples) > 0 {
+			value = guessedValue(s.Examples[0])
+		}
+		if t == "" {
+			if len(s.Type) > 0 {
+				t = s.Type[0]
+			} else if len(s.Properties) > 0 {
+				t = "object"
+			} else if s.Items != nil || len(s.PrefixItems) > 0 {
+				t = "array"
+			}
+		}
+	}
+	if proposals == 0 {
+		switch t {
+		case "boolean":
+			value = "$1"
+		case "string":
+			value = `"$1"`
+		case "object":
+			value = "{$1}"
+		case "array":
+			value = "[$1]"
+		case "number", "integer":
+			value = "${1:0}"
+		case "null":
+			value = "${1:null}"
+		default:
+			return insertText
+		}
+	}
+	if value == "" || proposals > 1 {
+		value = "$1"
+	}
+	return insertText + ": " + value + separatorAfter
+}
+
+// guessedValue returns the snippet to insert for the JSON value, with a
+// placeholder for literals.
+func guessedValue(value json.RawMessage) string {
+	text := compact(value)
+	switch {
+	case strings.HasPrefix(text, `"`):
+		return `"${1:` + escapeSnippet(text[1:len(text)-1]) + `}"`
+	case strings.HasPrefix(text, "{"), strings.HasPrefix(text, "["):
+		return escapeSnippet(indent(value))
+	}
+	return "${1:" + escapeSnippet(text) + "}"
+}
+
+// snippetBody returns the snippet to insert for the body of a default snippet, in
+// which strings starting with "^" are inserted without quotes.
+func snippetBody(body json.RawMessage) string {
+	text := indent(body)
+	var edits []jsonx.Edit
+	jsonx.Walk(text, jsonx.ParseOptions{}, jsonx.Visitor{
+		OnLiteralValue: func(value interface{}, offset, length int) {
+			if s, ok := value.(string); ok && strings.HasPrefix(s, "^") {
+				edits = append(edits, jsonx.Edit{Offset: offset, Length: length, Content: s[1:]})
+			}
+		},
+	})
+	result, err := jsonx.ApplyEdits(text, edits...)
+	if err != nil {
+		return text
+	}
+	return result
+}
+
+// indent returns the JSON value indented with tabs.
+func indent(value json.RawMessage) string {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, value, "", "\t"); err != nil {
+		return string(value)
+	}
+	return buf.String()
+}
+
+// escapeSnippet escapes the text for LSP snippet syntax.
+func escapeSnippet(text string) string {
+	return strings.NewReplacer(`\`, `\\`, "$", `\$`, "}", `\}`).Replace(text)
+}
@@ -0,0 +1,169 @@
+package schema
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sourcegraph/jsonx"
+)
+
+const completionSchema = `{
+	"type": "object",
+	"properties": {
+		"name": {"type": "string", "description": "The name."},
+		"enabled": {"type": "boolean", "markdownDescription": "Whether it is *enabled*."},
+		"size": {"type": "integer", "default": 10},
+		"mode": {"enum": ["fast", "slow"], "enumDescriptions": ["Fast mode.", "Slow mode."]},
+		"tags": {"type": "array", "items": {"enum": ["a", "b"]}},
+		"server": {
+			"type": "object",
+			"properties": {"host": {"type": "string", "default": "localhost"}, "port": {"type": "integer"}},
+			"defaultSnippets": [{"label": "local", "description": "A local server.", "body": {"host": "localhost", "port": "^${1:8080}"}}],
+		},
+		"ref": {"$ref": "#/$defs/level"},
+		"removed": false,
+	},
+	"$defs": {"level": {"description": "The log level.", "anyOf": [{"const": "debug"}, {"type": "null"}]}},
+}`
+
+func TestComplete(t *testing.T) {
+	s, err := Parse(completionSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	props := func(labels ...string) []string { return labels }
+	tests := map[string]struct {
+		input string // with | at the cursor
+		want  []CompletionItem
+		// wantLabels is checked instead of want if set
+		wantLabels []string
+	}{
+		"empty document": {
+			input: "|",
+			want: []CompletionItem{
+				{Label: "{}", Kind: ValueCompletion, InsertText: "{$1}", Range: jsonx.Range{Offset: 0}},
+			},
+		},
+		"empty object": {
+			input:      "{|}",
+			wantLabels: props("enabled", "mode", "name", "ref", "server", "size", "tags"),
+		},
+		"before the object": {
+			input: "|{}",
+		},
+		"present properties": {
+			input:      `{"name": "x", "size": 1, |}`,
+			wantLabels: props("enabled", "mode", "ref", "server", "tags"),
+		},
+		"property insert text": {
+			input: `{"name": "x", | "size": 1}`,
+			want: []CompletionItem{
+				{Label: "enabled", Kind: PropertyCompletion, MarkdownDescription: "Whether it is *enabled*.", InsertText: `"enabled": $1,`, Range: jsonx.Range{Offset: 14}},
+				{Label: "mode", Kind: PropertyCompletion, InsertText: `"mode": $1,`, Range: jsonx.Range{Offset: 14}},
+				{Label: "ref", Kind: PropertyCompletion, Description: "The log level.", InsertText: `"ref": "${1:debug}",`, Range: jsonx.Range{Offset: 14}},
+				{Label: "server", Kind: PropertyCompletion, InsertText: "\"server\": {\n\t\"host\": \"localhost\",\n\t\"port\": ${1:8080}\n},", Range: jsonx.Range{Offset: 14}},
+				{Label: "tags", Kind: PropertyCompletion, InsertText: `"tags": [$1],`, Range: jsonx.Range{Offset: 14}},
+			},
+		},
+		"property key": {
+			input: `{"na|": 1}`,
+			want: []CompletionItem{
+				{Label: "enabled", Kind: PropertyCompletion, MarkdownDescription: "Whether it is *enabled*.", InsertText: `"enabled"`, Range: jsonx.Range{Offset: 1, Length: 4}},
+				{Label: "mode", Kind: PropertyCompletion, InsertText: `"mode"`, Range: jsonx.Range{Offset: 1, Length: 4}},
+				{Label: "name", Kind: PropertyCompletion, Description: "The name.", InsertText: `"name"`, Range: jsonx.Range{Offset: 1, Length: 4}},
+				{Label: "ref", Kind: PropertyCompletion, Description: "The log level.", InsertText: `"ref"`, Range: jsonx.Range{Offset: 1, Length: 4}},
+				{Label: "server", Kind: PropertyCompletion, InsertText: `"server"`, Range: jsonx.Range{Offset: 1, Length: 4}},
+				{Label: "size", Kind: PropertyCompletion, InsertText: `"size"`, Range: jsonx.Range{Offset: 1, Length: 4}},
+				{Label: "tags", Kind: PropertyCompletion, InsertText: `"tags"`, Range: jsonx.Range{Offset: 1, Length: 4}},
+			},
+		},
+		"property key without a value": {
+			input:      `{"name": "x", "si|"}`,
+			wantLabels: props("enabled", "mode", "ref", "server", "size", "tags"),
+		},
+		"property default and type": {
+			input: `{"server": {|}}`,
+			want: []CompletionItem{
+				{Label: "host", Kind: PropertyCompletion, InsertText: `"host": "${1:localhost}"`, Range: jsonx.Range{Offset: 12}},
+				{Label: "port", Kind: PropertyCompletion, InsertText: `"port": ${1:0}`, Range: jsonx.Range{Offset: 12}},
+			},
+		},
+		"enum values": {
+			input: `{"mode": |}`,
+			want: []CompletionItem{
+				{Label: `"fast"`, Kind: ValueCompletion, Description: "Fast mode.", InsertText: `"fast"`, Range: jsonx.Range{Offset: 9}},
+				{Label: `"slow"`, Kind: ValueCompletion, Description: "Slow mode.", InsertText: `"slow"`, Range: jsonx.Range{Offset: 9}},
+			},
+		},
+		"enum values with a separator": {
+			input:      `{"mode": |"name": "x"}`,
+			wantLabels: props(`"fast"`, `"slow"`),
+		},
+		"replaced value": {
+			input: `{"mode": "fa|", "name": "x"}`,
+			want: []CompletionItem{
+				{Label: `"fast"`, Kind: ValueCompletion, Description: "Fast mode.", InsertText: `"fast"`, Range: jsonx.Range{Offset: 9, Length: 4}},
+				{Label: `"slow"`, Kind: ValueCompletion, Description: "Slow mode.", InsertText: `"slow"`, Range: jsonx.Range{Offset: 9, Length: 4}},
+			},
+		},
+		"booleans": {
+			input:      `{"enabled": |}`,
+			wantLabels: props("true", "false"),
+		},
+		"default value": {
+			input: `{"size": |}`,
+			want: []CompletionItem{
+				{Label: "10", Kind: ValueCompletion, InsertText: "10", Range: jsonx.Range{Offset: 9}},
+			},
+		},
+		"default snippets": {
+			input: `{"server": |}`,
+			want: []CompletionItem{
+				{Label: "local", Kind: ValueCompletion, Description: "A local server.", InsertText: "{\n\t\"host\": \"localhost\",\n\t\"port\": ${1:8080}\n}", Range: jsonx.Range{Offset: 11}},
+				{Label: "{}", Kind: ValueCompletion, InsertText: "{$1}", Range: jsonx.Range{Offset: 11}},
+			},
+		},
+		"array items": {
+			input:      `{"tags": ["a", |]}`,
+			wantLabels: props(`"a"`, `"b"`),
+		},
+		"ref and any of": {
+			input:      `{"ref": |}`,
+			wantLabels: props(`"debug"`, "null"),
+		},
+		"before the colon": {
+			input: `{"mode" |: 1}`,
+		},
+		"after the value": {
+			input:      `{"mode": "fast" |}`,
+			wantLabels: props("enabled", "name", "ref", "server", "size", "tags"),
+		},
+		"after the object": {
+			input: `{"server": {}|}`,
+		},
+		"unknown property": {
+			input: `{"other": |}`,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			offset := len([]rune(test.input[:strings.Index(test.input, "|")]))
+			input := strings.Replace(test.input, "|", "", 1)
+			got := Complete(input, offset, s)
+			if test.wantLabels != nil {
+				var labels []string
+				for _, item := range got {
+					labels = append(labels, item.Label)
+				}
+				if !reflect.DeepEqual(labels, test.wantLabels) {
+					t.Errorf("got labels %q, want %q", labels, test.wantLabels)
+				}
+				return
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got items\n%+v\nwant\n%+v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,302 @@
+package schema
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/sourcegraph/jsonx"
+)
+
+// ComputeRequiredPropertyEdits returns the edits necessary to add the properties that
+// the schema requires and that are missing from the objects in the JSON document
+// text, including the root object of an empty document. It also returns the errors
+// from parsing the document.
+//
+// A missing property is added after the existing properties of its object, with the
+// default value from its schema (or else its const, or an empty value of its type),
+// and the properties required by that value are added to it in turn. The rest of the
+// document, including comments, is left unchanged, and the edits are formatted
+// according to the options (see jsonx.ComputePropertyEdit).
+func ComputeRequiredPropertyEdits(text string, s *Schema, options jsonx.FormatOptions) ([]jsonx.Edit, []jsonx.ParseErrorCode, error) {
+	root, parseErrors := jsonx.ParseTree(text, parseOptions)
+	f := &filler{tracker: tracker{text: text}, options: options}
+	if _, err := f.fill(nil, root, []*Schema{s}, map[*Schema]bool{}); err != nil {
+		return nil, parseErrors, err
+	}
+	return f.edits(), parseErrors, nil
+}
+
+// A filler adds the missing required properties to a document.
+type filler struct {
+	tracker
+	options jsonx.FormatOptions
+}
+
+// fill adds the missing required properties to the value at the path and its
+// descendants, given its node in the parse tree of the current text (nil for an
+// empty document) and the schemas for the value. The adding set contains the schemas
+// of the properties being added, to stop at recursive required properties. It
+// reports whether it changed the text, which makes the parse tree out of date.
+func (f *filler) fill(path jsonx.Path, node *jsonx.Node, schemas []*Schema, adding map[*Schema]bool) (changed bool, err error) {
+	if node == nil && len(path) > 0 {
+		return false, nil
+	}
+	schemas = applicable(schemas, node)
+
+	if node == nil || node.Type == jsonx.Object {
+		present := map[string]bool{}
+		if node != nil {
+			for _, property := range node.Children {
+				if len(property.Children) >= 2 {
+					present[property.Children[0].Value.(string)] = true
+				}
+			}
+		}
+		added := map[string][]*Schema{} // the schemas of the added properties, by name
+		for _, name := range required(schemas) {
+			if present[name] {
+				continue
+			}
+			segment := jsonx.Segment{IsProperty: true, Property: name}
+			propertySchemas := applicable(childSchemas(schemas, segment), nil)
+			if containsAny(adding, propertySchemas) {
+				continue // a recursive required property
+			}
+			edits, _, err := jsonx.ComputePropertyEdit(f.text, appendPath(path, segment), defaultValue(propertySchemas), nil, f.options)
+			if err != nil {
+				return changed, err
+			}
+			if err := f.apply(edits); err != nil {
+				return changed, err
+			}
+			changed = true
+			present[name] = true
+			added[name] = propertySchemas
+		}
+
+		if changed {
+			// Find the object in the edited text.
+			if node = f.find(path); node == nil || node.Type != jsonx.Object {
+				return changed, nil
+			}
+		} else if node == nil {
+			return false, nil
+		}
+		properties := firstProperties(node)
+		for i := 0; i < len(properties); i++ {
+			name := properties[i].Children[0].Value.(string)
+			segment := jsonx.Segment{IsProperty: true, Property: name}
+			childAdding := adding
+			if propertySchemas, ok := added[name]; ok {
+				childAdding = map[*Schema]bool{}
+				for s := range adding {
+					childAdding[s] = true
+				}
+				for _, s := range propertySchemas {
+					childAdding[s] = true
+				}
+			}
+			childChanged, err := f.fill(appendPath(path, segment), properties[i].Children[1], childSchemas(schemas, segment), childAdding)
+			if err != nil {
+				return changed, err
+			}
+			if childChanged {
+				// The parse tree is out of date, so find the object in the edited text.
+				changed = true
+				if node = f.find(path); node == nil || node.Type != jsonx.Object {
+					return changed, nil
+				}
+				properties = firstProperties(node)
+			}
+		}
+	} else if node.Type == jsonx.Array {
+		for i := 0; i < len(node.Children); i++ {
+			segment := jsonx.Segment{Index: i}
+			childChanged, err := f.fill(appendPath(path, segment), node.Children[i], childSchemas(schemas, segment), adding)
+			if err != nil {
+				return changed, err
+			}
+			if childChanged {
+				// The parse tree is out of date, so find the array in the edited text.
+				changed = true
+				if node = f.find(path); node == nil || node.Type != jsonx.Array {
+					return changed, nil
+				}
+			}
+		}
+	}
+	return changed, nil
+}
+
+// find returns the node at the path in the parse tree of the current text.
+func (f *filler) find(path jsonx.Path) *jsonx.Node {
+	root, _ := jsonx.ParseTree(f.text, parseOptions)
+	return jsonx.FindNodeAtLocation(root, path)
+}
+
+// firstProperties returns the properties of the object node that have a value,
+// except those with the same name as an earlier property (which paths can't refer
+// to).
+func firstProperties(node *jsonx.Node) []*jsonx.Node {
+	var properties []*jsonx.Node
+	seen := map[string]bool{}
+	for _, property := range node.Children {
+		if len(property.Children) < 2 {
+			continue
+		}
+		if name := property.Children[0].Value.(string); !seen[name] {
+			seen[name] = true
+			properties = append(properties, property)
+		}
+	}
+	return properties
+}
+
+// required returns the names of the properties required by the schemas, in order.
+func required(schemas []*Schema) []string {
+	var names []string
+	seen := map[string]bool{}
+	for _, s := range schemas {
+		for _, name := range s.Required {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+func containsAny(set map[*Schema]bool, schemas []*Schema) bool {
+	for _, s := range schemas {
+		if set[s] {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultValue returns the value to add for a property with the schemas: the first
+// default or const, or else an empty value of the first type.
+func defaultValue(schemas []*Schema) json.RawMessage {
+	for _, s := range schemas {
+		if len(s.Default) > 0 {
+			return s.Default
+		}
+	}
+	for _, s := range schemas {
+		if len(s.Const) > 0 {
+			return s.Const
+		}
+	}
+	for _, s := range schemas {
+		types := s.Type
+		if len(types) == 0 && (len(s.Properties) > 0 || len(s.Required) > 0) {
+			types = Types{"object"}
+		}
+		if len(types) == 0 && (s.Items != nil || len(s.PrefixItems) > 0) {
+			types = Types{"array"}
+		}
+		for _, t := range types {
+			switch t {
+			case "object":
+				return json.RawMessage("{}")
+			case "array":
+				return json.RawMessage("[]")
+			case "string":
+				return json.RawMessage(`""`)
+			case "number", "integer":
+				return json.RawMessage("0")
+			case "boolean":
+				return json.RawMessage("false")
+			}
+		}
+	}
+	return json.RawMessage("null")
+}
+
+// A tracker applies edits to a document, and keeps track of the regions of the
+// original document that they changed.
+type tracker struct {
+	text    string          // the document with the edits applied
+	regions []changedRegion // sorted and disjoint
+}
+
+// A changedRegion is a region of the original document and the text that replaces
+// it in the edited document.
+type changedRegion struct {
+	original jsonx.Range
+	current  jsonx.Range
+}
+
+// apply applies the edits (relative to the current text) to the current text.
+func (t *tracker) apply(edits []jsonx.Edit) error {
+	text, err := jsonx.ApplyEdits(t.text, edits...)
+	if err != nil {
+		return err
+	}
+	t.text = text
+
+	// Track the edits from the end of the document, so that the offsets of the
+	// remaining edits are unaffected.
+	sorted := make([]jsonx.Edit, len(edits))
+	copy(sorted, edits)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Offset > sorted[j].Offset })
+	for _, edit := range sorted {
+		t.track(edit)
+	}
+	return nil
+}
+
+func (t *tracker) track(edit jsonx.Edit) {
+	editStart, editEnd := edit.Offset, edit.Offset+edit.Length
+	delta := len([]rune(edit.Content)) - edit.Length
+
+	// Merge the regions that overlap or touch the edit.
+	start, end := editStart, editEnd
+	var before, merged int // the length changes of the regions before the edit and of the merged regions
+	var regions []changedRegion
+	for _, r := range t.regions {
+		rStart, rEnd := r.current.Offset, r.current.Offset+r.current.Length
+		switch {
+		case rEnd < editStart:
+			before += r.current.Length - r.original.Length
+			regions = append(regions, r)
+		case rStart > editEnd:
+			r.current.Offset += delta
+			regions = append(regions, r)
+		default:
+			merged += r.current.Length - r.original.Length
+			if rStart < start {
+				start = rStart
+			}
+			if rEnd > end {
+				end = rEnd
+			}
+		}
+	}
+	region := changedRegion{
+		original: jsonx.Range{Offset: start - before, Length: end - start - merged},
+		current:  jsonx.Range{Offset: start, Length: end - start + delta},
+	}
+
+	i := sort.Search(len(regions), func(i int) bool { return regions[i].current.Offset > start })
+	regions = append(regions, changedRegion{})
+	copy(regions[i+1:], regions[i:])
+	regions[i] = region
+	t.regions = regions
+}
+
+// edits returns the edits that change the original document to the current text.
+func (t *tracker) edits() []jsonx.Edit {
+	chars := []rune(t.text)
+	edits := make([]jsonx.Edit, 0, len(t.regions))
+	for _, r := range t.regions {
+		content := string(chars[r.current.Offset : r.current.Offset+r.current.Length])
+		if r.original.Length == 0 && content == "" {
+			continue
+		}
+		edits = append(edits, jsonx.Edit{Offset: r.original.Offset, Length: r.original.Length, Content: content})
+	}
+	return edits
+}
@@ -0,0 +1,216 @@
+package schema
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sourcegraph/jsonx"
+)
+
+func TestComputeRequiredPropertyEdits(t *testing.T) {
+	const schema = `{
+		"type": "object",
+		"required": ["name", "server"],
+		"properties": {
+			"name": {"type": "string", "default": "app"},
+			"server": {
+				"type": "object",
+				"required": ["host", "port", "tls"],
+				"properties": {
+					"host": {"default": "localhost"},
+					"port": {"type": "integer"},
+					"tls": {"$ref": "#/$defs/tls"},
+				},
+			},
+			"plugins": {"items": {"required": ["id"], "properties": {"id": {"const": "x"}}}},
+			"node": {"$ref": "#/$defs/node"},
+		},
+		"$defs": {
+			"tls": {"type": "object", "required": ["enabled"], "properties": {"enabled": {"type": "boolean"}}},
+			"node": {"type": "object", "required": ["child"], "properties": {"child": {"$ref": "#/$defs/node"}}},
+		},
+	}`
+	s, err := Parse(schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	options := jsonx.FormatOptions{TabSize: 2, InsertSpaces: true}
+	tests := map[string]struct {
+		input string
+		want  string
+	}{
+		"empty document": {
+			input: "",
+			want: `{
+  "name": "app",
+  "server": {
+    "host": "localhost",
+    "port": 0,
+    "tls": {
+      "enabled": false
+    }
+  }
+}`,
+		},
+		"complete": {
+			input: `{"name": "x", "server": {"host": "h", "port": 1, "tls": {"enabled": true}}}`,
+			want:  `{"name": "x", "server": {"host": "h", "port": 1, "tls": {"enabled": true}}}`,
+		},
+		"comments and order": {
+			input: `{
+  // The server.
+  "server": {
+    // The port.
+    "port": 8080
+  },
+  "name": "x" // the name
+}`,
+			want: `{
+  // The server.
+  "server": {
+    // The port.
+    "port": 8080,
+    "host": "localhost",
+    "tls": {
+      "enabled": false
+    }
+  },
+  "name": "x" // the name
+}`,
+		},
+		"array elements": {
+			input: `{
+  "name": "x",
+  "server": {"host": "h", "port": 1, "tls": {"enabled": true}},
+  "plugins": [
+    {},
+    {"id": "y"}
+  ]
+}`,
+			want: `{
+  "name": "x",
+  "server": {"host": "h", "port": 1, "tls": {"enabled": true}},
+  "plugins": [
+    {
+      "id": "x"
+    },
+    {"id": "y"}
+  ]
+}`,
+		},
+		"recursive required property": {
+			input: `{
+  "name": "x",
+  "server": {"host": "h", "port": 1, "tls": {"enabled": true}},
+  "node": {}
+}`,
+			want: `{
+  "name": "x",
+  "server": {"host": "h", "port": 1, "tls": {"enabled": true}},
+  "node": {
+    "child": {}
+  }
+}`,
+		},
+		"other root value": {
+			input: `[1]`,
+			want:  `[1]`,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			edits, _, err := ComputeRequiredPropertyEdits(test.input, s, options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := jsonx.ApplyEdits(test.input, edits...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want {
+				t.Errorf("got\n%s\nwant\n%s", got, test.want)
+			}
+		})
+	}
+
+	t.Run("separate edits", func(t *testing.T) {
+		input := `{
+  "server": {
+    "port": 1,
+    "tls": {}
+  },
+  // comment
+  "other": {}
+}`
+		edits, _, err := ComputeRequiredPropertyEdits(input, s, options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []jsonx.Edit{
+			{Offset: 43, Content: "\n      \"enabled\": false\n    "},
+			{Offset: 44, Content: ",\n    \"host\": \"localhost\""},
+			{Offset: 76, Content: ",\n  \"name\": \"app\""},
+		}
+		if !reflect.DeepEqual(edits, want) {
+			t.Errorf("got edits %+v, want %+v", edits, want)
+		}
+	})
+
+	t.Run("many properties", func(t *testing.T) {
+		// Filling a document must not parse it again for each value, which
+		// would make the cost quadratic in the size of the document.
+		var b strings.Builder
+		b.WriteString(`{"name": "x", "server": {"host": "h", "port": 1, "tls": {"enabled": true}}, "plugins": [`)
+		for i := 0; i < 300; i++ {
+			fmt.Fprintf(&b, `{"id": "x", "p%d": {"a": [1, 2]}}, `, i)
+		}
+		b.WriteString(`{}]}`)
+		input := b.String()
+
+		parseAllocs := testing.AllocsPerRun(1, func() { jsonx.ParseTree(input, parseOptions) })
+		allocs := testing.AllocsPerRun(1, func() {
+			edits, _, err := ComputeRequiredPropertyEdits(input, s, options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(edits) != 1 {
+				t.Errorf("got %d edits, want 1", len(edits))
+			}
+		})
+		if allocs > 10*parseAllocs {
+			t.Errorf("got %.0f allocations, want at most 10 times the %.0f allocations of parsing", allocs, parseAllocs)
+		}
+	})
+}
+
+func TestTracker(t *testing.T) {
+	const original = "abcdefghij"
+	steps := [][]jsonx.Edit{
+		{{Offset: 2, Length: 2, Content: "XYZ"}},                         // abXYZefghij
+		{{Offset: 9, Content: "1"}, {Offset: 0, Length: 1}},              // bXYZefgh1ij
+		{{Offset: 3, Length: 3, Content: "-"}},                           // bXY-gh1ij
+		{{Offset: 6, Length: 1, Content: ""}, {Offset: 9, Content: "!"}}, // bXY-ghij!
+	}
+	tr := tracker{text: original}
+	for _, edits := range steps {
+		if err := tr.apply(edits); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if want := "bXY-ghij!"; tr.text != want {
+		t.Fatalf("got text %q, want %q", tr.text, want)
+	}
+	want := []jsonx.Edit{
+		{Offset: 0, Length: 1},
+		{Offset: 2, Length: 4, Content: "XY-"},
+		{Offset: 10, Content: "!"},
+	}
+	if got := tr.edits(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got edits %+v, want %+v", got, want)
+	}
+	if got, err := jsonx.ApplyEdits(original, tr.edits()...); err != nil || got != tr.text {
+		t.Errorf("got %q (error %v) from applying the edits, want %q", got, err, tr.text)
+	}
+}
@@ -0,0 +1,94 @@
+// This file was ported from https://github.com/microsoft/vscode-json-languageservice/blob/main/src/services/jsonHover.ts,
+// which is licensed as follows:
+//
+// Copyright (c) Microsoft Corporation. All rights reserved. Licensed under the MIT License.
+
+package schema
+
+import "github.com/sourcegraph/jsonx"
+
+// HoverInfo is the documentation from a schema for a value in a JSON document.
+type HoverInfo struct {
+	Path                jsonx.Path  // the key path of the value
+	Range               jsonx.Range // the range to highlight: the property's key, or the value
+	Title               string
+	Description         string // in plain text
+	MarkdownDescription string // in Markdown
+
+	// Values are the values allowed by the enum and const of the schema, as JSON.
+	Values []string
+
+	// ValueDescription and MarkdownValueDescription describe the value in the
+	// document, if the schema's enum has descriptions for it.
+	ValueDescription         string
+	MarkdownValueDescription string
+}
+
+// Hover returns the documentation from the schema for the property key or value
+// at the character offset in the JSON document text, or nil if there is none.
+//
+// The documentation is the first title and description found in the schemas that
+// apply to the value, following $ref, allOf, anyOf, oneOf, if/then/else and
+// patternProperties. There is no documentation inside objects and arrays (except
+// at their brackets).
+//
+// Source: https://github.com/microsoft/vscode-json-languageservice/blob/main/src/services/jsonHover.ts
+func Hover(text string, offset int, s *Schema) *HoverInfo {
+	root, _ := jsonx.ParseTree(text, parseOptions)
+	node := jsonx.FindNodeAtOffset(root, offset, false)
+	if node == nil || node.Type == jsonx.Property {
+		return nil
+	}
+	if (node.Type == jsonx.Object || node.Type == jsonx.Array) && offset > node.Offset+1 && offset < node.Offset+node.Length-1 {
+		return nil // inside the object or array
+	}
+
+	info := &HoverInfo{Path: jsonx.NodePath(node), Range: jsonx.Range{Offset: node.Offset, Length: node.Length}}
+	value := node
+	if node.Parent != nil && node.Parent.Type == jsonx.Property && node.Parent.Children[0] == node {
+		value = nil // at a property's key
+		if len(node.Parent.Children) >= 2 {
+			value = node.Parent.Children[1]
+		}
+	}
+	var current interface{}
+	if value != nil {
+		current = jsonx.NodeValue(*value)
+	}
+
+	seen := map[string]bool{}
+	for _, sub := range s.schemasAt(root, info.Path) {
+		if info.Title == "" {
+			info.Title = sub.Title
+		}
+		if info.Description == "" {
+			info.Description = sub.Description
+		}
+		if info.MarkdownDescription == "" {
+			info.MarkdownDescription = sub.MarkdownDescription
+		}
+		allowed := sub.Enum
+		if len(sub.Const) > 0 {
+			allowed = append(allowed[:len(allowed):len(allowed)], sub.Const)
+		}
+		for i, v := range allowed {
+			if text := compact(v); !seen[text] {
+				seen[text] = true
+				info.Values = append(info.Values, text)
+			}
+			if value == nil || i >= len(sub.Enum) || !equal(current, decode(v)) {
+				continue
+			}
+			if info.ValueDescription == "" && i < len(sub.EnumDescriptions) {
+				info.ValueDescription = sub.EnumDescriptions[i]
+			}
+			if info.MarkdownValueDescription == "" && i < len(sub.MarkdownEnumDescriptions) {
+				info.MarkdownValueDescription = sub.MarkdownEnumDescriptions[i]
+			}
+		}
+	}
+	if info.Title == "" && info.Description == "" && info.MarkdownDescription == "" && len(info.Values) == 0 {
+		return nil
+	}
+	return info
+}
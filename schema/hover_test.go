@@ -0,0 +1,96 @@
+package schema
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sourcegraph/jsonx"
+)
+
+func TestHover(t *testing.T) {
+	s, err := Parse(`{
+		"title": "Settings",
+		"properties": {
+			"name": {"type": "string", "description": "The name."},
+			"mode": {
+				"title": "Mode",
+				"enum": ["fast", "slow"],
+				"enumDescriptions": ["Fast mode.", "Slow mode."],
+				"markdownDescription": "The *mode*.",
+			},
+			"level": {"$ref": "#/$defs/level"},
+			"nested": {"allOf": [{"properties": {"a": {"description": "From allOf."}}}]},
+		},
+		"patternProperties": {"^x-": {"description": "An extension."}},
+		"$defs": {"level": {"description": "The level.", "oneOf": [{"const": 1}, {"const": 2}]}},
+	}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]struct {
+		input string // with | at the cursor
+		want  *HoverInfo
+	}{
+		"empty document": {
+			input: "|",
+		},
+		"root object": {
+			input: `|{"name": "x"}`,
+			want:  &HoverInfo{Path: jsonx.Path{}, Range: jsonx.Range{Offset: 0, Length: 13}, Title: "Settings"},
+		},
+		"inside an object": {
+			input: `{"name": "x",| "mode": "fast"}`,
+		},
+		"property key": {
+			input: `{"na|me": "x"}`,
+			want:  &HoverInfo{Path: jsonx.PropertyPath("name"), Range: jsonx.Range{Offset: 1, Length: 6}, Description: "The name."},
+		},
+		"property value": {
+			input: `{"name": "|x"}`,
+			want:  &HoverInfo{Path: jsonx.PropertyPath("name"), Range: jsonx.Range{Offset: 9, Length: 3}, Description: "The name."},
+		},
+		"property without a value": {
+			input: `{"na|me"}`,
+			want:  &HoverInfo{Path: jsonx.PropertyPath("name"), Range: jsonx.Range{Offset: 1, Length: 6}, Description: "The name."},
+		},
+		"enum": {
+			input: `{"mode": "sl|ow"}`,
+			want: &HoverInfo{
+				Path:                jsonx.PropertyPath("mode"),
+				Range:               jsonx.Range{Offset: 9, Length: 6},
+				Title:               "Mode",
+				MarkdownDescription: "The *mode*.",
+				Values:              []string{`"fast"`, `"slow"`},
+				ValueDescription:    "Slow mode.",
+			},
+		},
+		"ref and one of": {
+			input: `{"le|vel": 3}`,
+			want:  &HoverInfo{Path: jsonx.PropertyPath("level"), Range: jsonx.Range{Offset: 1, Length: 7}, Description: "The level.", Values: []string{"1", "2"}},
+		},
+		"all of": {
+			input: `{"nested": {"|a": true}}`,
+			want:  &HoverInfo{Path: jsonx.PropertyPath("nested", "a"), Range: jsonx.Range{Offset: 12, Length: 3}, Description: "From allOf."},
+		},
+		"pattern properties": {
+			input: `{"x-foo": |1}`,
+			want:  &HoverInfo{Path: jsonx.PropertyPath("x-foo"), Range: jsonx.Range{Offset: 10, Length: 1}, Description: "An extension."},
+		},
+		"no documentation": {
+			input: `{"other": |1}`,
+		},
+		"colon": {
+			input: `{"name"|: "x"}`,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			offset := len([]rune(test.input[:strings.Index(test.input, "|")]))
+			input := strings.Replace(test.input, "|", "", 1)
+			if got := Hover(input, offset, s); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
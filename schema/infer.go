@@ -0,0 +1,179 @@
+package schema
+
+import (
+	"encoding/json"
+
+	"github.com/sourcegraph/jsonx"
+	"github.com/sourcegraph/jsonx/internal/comments"
+)
+
+// A Sample is a JSON document from which to infer a schema.
+type Sample struct {
+	Root *jsonx.Node // the parse tree of the document
+	Text string      // the document text, for the comments (optional)
+}
+
+// maxEnumValues is the maximum number of distinct strings that InferSchema
+// collects as an enum.
+const maxEnumValues = 5
+
+// InferSchema returns a 2020-12 schema that describes the values of the sample
+// documents, as a starting point for writing a schema for them.
+//
+// The values at the same location in the documents (and the elements of an array)
+// are unified: the schema allows all of their types, and describes the properties
+// of all of their objects. The properties that all of the objects have are
+// required. The values of a string property are an enum if there are at most 5
+// distinct values, each of which occurs more than once. The comments on the lines
+// before a property become the description of its schema.
+func InferSchema(samples ...Sample) *Schema {
+	root := &summary{}
+	for _, sample := range samples {
+		if sample.Root == nil {
+			continue
+		}
+		var leading map[int]string
+		if sample.Text != "" {
+			leading = comments.Leading(sample.Text)
+		}
+		root.add(sample.Root, leading)
+	}
+	s := root.schema()
+	s.Dialect = "https://json-schema.org/draft/2020-12/schema"
+	return s
+}
+
+// A summary is the unified description of one or more JSON values.
+type summary struct {
+	types map[string]bool // the JSON Schema types of the values
+
+	// strings
+	distinct []string       // the distinct strings, in order, up to maxEnumValues+1
+	counts   map[string]int // the number of occurrences of the distinct strings
+
+	// objects
+	objects    int         // the number of objects
+	properties []*property // in order of appearance
+
+	// arrays
+	items *summary // the elements of the arrays
+}
+
+type property struct {
+	name        string
+	value       *summary
+	count       int    // the number of objects with the property
+	description string // the comments before the property
+}
+
+// add adds the value of the node to the summary. The comments are the comments
+// before the tokens of the node's document, by token offset.
+func (u *summary) add(node *jsonx.Node, comments map[int]string) {
+	if u.types == nil {
+		u.types = map[string]bool{}
+	}
+	switch node.Type {
+	case jsonx.Null:
+		u.types["null"] = true
+	case jsonx.Boolean:
+		u.types["boolean"] = true
+	case jsonx.Number:
+		if isInteger(node.Value.(json.Number)) {
+			u.types["integer"] = true
+		} else {
+			u.types["number"] = true
+		}
+	case jsonx.String:
+		u.types["string"] = true
+		value := node.Value.(string)
+		if u.counts == nil {
+			u.counts = map[string]int{}
+		}
+		if _, ok := u.counts[value]; !ok && len(u.distinct) <= maxEnumValues {
+			u.distinct = append(u.distinct, value)
+		}
+		if len(u.distinct) <= maxEnumValues {
+			u.counts[value]++
+		}
+	case jsonx.Array:
+		u.types["array"] = true
+		if u.items == nil {
+			u.items = &summary{}
+		}
+		for _, child := range node.Children {
+			u.items.add(child, comments)
+		}
+	case jsonx.Object:
+		u.types["object"] = true
+		u.objects++
+		seen := map[string]bool{}
+		for _, child := range node.Children {
+			key := child.Children[0]
+			name := key.Value.(string)
+			if len(child.Children) < 2 || seen[name] {
+				continue
+			}
+			seen[name] = true
+			p := u.property(name)
+			p.count++
+			if p.description == "" {
+				p.description = comments[key.Offset]
+			}
+			p.value.add(child.Children[1], comments)
+		}
+	}
+}
+
+// property returns the object property with the name, adding it if necessary.
+func (u *summary) property(name string) *property {
+	for _, p := range u.properties {
+		if p.name == name {
+			return p
+		}
+	}
+	p := &property{name: name, value: &summary{}}
+	u.properties = append(u.properties, p)
+	return p
+}
+
+// schema returns the schema for the summarized values.
+func (u *summary) schema() *Schema {
+	s := &Schema{}
+	for _, t := range []string{"object", "array", "string", "integer", "number", "boolean", "null"} {
+		if u.types[t] && !(t == "integer" && u.types["number"]) {
+			s.Type = append(s.Type, t)
+		}
+	}
+
+	if len(s.Type) == 1 && s.Type[0] == "string" && len(u.distinct) <= maxEnumValues {
+		enum := true
+		for _, value := range u.distinct {
+			if u.counts[value] < 2 {
+				enum = false
+			}
+		}
+		if enum {
+			for _, value := range u.distinct {
+				data, _ := json.Marshal(value)
+				s.Enum = append(s.Enum, data)
+			}
+		}
+	}
+
+	if u.objects > 0 {
+		s.Properties = map[string]*Schema{}
+		for _, p := range u.properties {
+			ps := p.value.schema()
+			ps.Description = p.description
+			s.Properties[p.name] = ps
+			if p.count == u.objects {
+				s.Required = append(s.Required, p.name)
+			}
+		}
+	}
+
+	if u.items != nil && len(u.items.types) > 0 {
+		s.Items = u.items.schema()
+	}
+	return s
+}
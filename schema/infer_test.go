@@ -0,0 +1,90 @@
+package schema
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/sourcegraph/jsonx"
+)
+
+func TestInferSchema(t *testing.T) {
+	tests := map[string]struct {
+		documents []string
+		want      string
+	}{
+		"types": {
+			documents: []string{`{"s": "x", "i": 1, "n": 1.5, "b": true, "z": null, "a": [1, 2.5], "o": {}}`},
+			want:      `{"type":"object","properties":{"a":{"type":"array","items":{"type":"number"}},"b":{"type":"boolean"},"i":{"type":"integer"},"n":{"type":"number"},"o":{"type":"object"},"s":{"type":"string"},"z":{"type":"null"}},"required":["s","i","n","b","z","a","o"]}`,
+		},
+		"unified documents": {
+			documents: []string{
+				`{"name": "a", "port": 80, "tls": null}`,
+				`{"name": "b", "port": 1.5, "tls": {"cert": "c"}, "extra": true}`,
+			},
+			want: `{"type":"object","properties":{"extra":{"type":"boolean"},"name":{"type":"string"},"port":{"type":"number"},"tls":{"type":["object","null"],"properties":{"cert":{"type":"string"}},"required":["cert"]}},"required":["name","port","tls"]}`,
+		},
+		"array elements": {
+			documents: []string{`[{"id": 1, "tags": []}, {"id": 2}, "x"]`},
+			want:      `{"type":"array","items":{"type":["object","string"],"properties":{"id":{"type":"integer"},"tags":{"type":"array"}},"required":["id"]}}`,
+		},
+		"enums": {
+			documents: []string{
+				`{"mode": "fast", "name": "a", "level": "x"}`,
+				`{"mode": "slow", "name": "b", "level": "x"}`,
+				`{"mode": "fast", "name": "b", "level": 1}`,
+				`{"mode": "slow", "name": "c"}`,
+			},
+			want: `{"type":"object","properties":{"level":{"type":["string","integer"]},"mode":{"type":"string","enum":["fast","slow"]},"name":{"type":"string"}},"required":["mode","name"]}`,
+		},
+		"comments": {
+			documents: []string{`{
+				// The name.
+				"name": "x", // not a description
+				/*
+				 * The size
+				 * in bytes.
+				 */
+				"size": {
+					// The unit.
+					"unit": "B",
+				},
+			}`},
+			want: `{"type":"object","properties":{"name":{"description":"The name.","type":"string"},"size":{"description":"The size\nin bytes.","type":"object","properties":{"unit":{"description":"The unit.","type":"string"}},"required":["unit"]}},"required":["name","size"]}`,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var samples []Sample
+			for _, text := range test.documents {
+				root, errors := jsonx.ParseTree(text, parseOptions)
+				if len(errors) > 0 {
+					t.Fatalf("parse errors: %v", errors)
+				}
+				samples = append(samples, Sample{Root: root, Text: text})
+			}
+			s := InferSchema(samples...)
+			if s.Dialect != "https://json-schema.org/draft/2020-12/schema" {
+				t.Errorf("got $schema %q", s.Dialect)
+			}
+			s.Dialect = ""
+			data, err := json.Marshal(s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != test.want {
+				t.Errorf("got  %s\nwant %s", data, test.want)
+			}
+
+			// The documents must be valid according to the inferred schema.
+			parsed, err := Parse(string(data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, sample := range samples {
+				if errors := parsed.Validate(sample.Root); len(errors) > 0 {
+					t.Errorf("got validation errors %v", errors)
+				}
+			}
+		})
+	}
+}
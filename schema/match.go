@@ -0,0 +1,124 @@
+package schema
+
+import "github.com/sourcegraph/jsonx"
+
+// schemasAt returns the schemas that apply to the value at the path in the
+// document with the parse tree root (see applicable). The value need not exist.
+func (s *Schema) schemasAt(root *jsonx.Node, path jsonx.Path) []*Schema {
+	node := root
+	schemas := applicable([]*Schema{s}, node)
+	for _, segment := range path {
+		if node != nil {
+			node = jsonx.FindNodeAtLocation(node, jsonx.Path{segment})
+		}
+		schemas = applicable(childSchemas(schemas, segment), node)
+	}
+	return schemas
+}
+
+// applicable returns the schemas and the subschemas that they apply to the node
+// (which may be nil if the value does not exist) through $ref, allOf, anyOf, oneOf
+// and if/then/else. For anyOf and oneOf, it includes only the subschemas that the
+// node matches most closely, or all of them if the node is nil.
+func applicable(schemas []*Schema, node *jsonx.Node) []*Schema {
+	var result []*Schema
+	seen := map[*Schema]bool{}
+	var add func(s *Schema)
+	add = func(s *Schema) {
+		if s == nil || seen[s] {
+			return
+		}
+		seen[s] = true
+		result = append(result, s)
+
+		add(s.ref)
+		for _, sub := range s.AllOf {
+			add(sub)
+		}
+		for _, sub := range closestMatches(s.AnyOf, node) {
+			add(sub)
+		}
+		for _, sub := range closestMatches(s.OneOf, node) {
+			add(sub)
+		}
+		if s.If != nil {
+			if node == nil {
+				add(s.Then)
+				add(s.Else)
+			} else if len(newValidator().try(s.If, node, nil)) == 0 {
+				add(s.Then)
+			} else {
+				add(s.Else)
+			}
+		}
+	}
+	for _, s := range schemas {
+		add(s)
+	}
+	return result
+}
+
+// closestMatches returns the schemas for which the node has the fewest validation
+// errors, or all of them if the node is nil.
+func closestMatches(schemas []*Schema, node *jsonx.Node) []*Schema {
+	if node == nil || len(schemas) == 0 {
+		return schemas
+	}
+	var matches []*Schema
+	fewest := -1
+	for _, s := range schemas {
+		errors := len(newValidator().try(s, node, nil))
+		if fewest == -1 || errors < fewest {
+			matches, fewest = nil, errors
+		}
+		if errors == fewest {
+			matches = append(matches, s)
+		}
+	}
+	return matches
+}
+
+// childSchemas returns the schemas of the schemas for the property or array
+// element of the segment.
+func childSchemas(schemas []*Schema, segment jsonx.Segment) []*Schema {
+	var result []*Schema
+	for _, s := range schemas {
+		if segment.IsProperty {
+			result = append(result, s.propertySchemas(segment.Property)...)
+		} else if item := s.itemSchema(segment.Index); item != nil {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// propertySchemas returns the schemas for the value of the object property with
+// the name: the schema of the property and of the matching patternProperties, or
+// else the additionalProperties schema.
+func (s *Schema) propertySchemas(name string) []*Schema {
+	var schemas []*Schema
+	if sub, ok := s.Properties[name]; ok {
+		schemas = append(schemas, sub)
+	}
+	additional := len(schemas) == 0
+	for _, p := range s.patternProperties {
+		if p.pattern == nil {
+			additional = false // the property might match the pattern
+		} else if p.pattern.MatchString(name) {
+			schemas = append(schemas, p.schema)
+			additional = false
+		}
+	}
+	if additional && s.AdditionalProperties != nil {
+		schemas = append(schemas, s.AdditionalProperties)
+	}
+	return schemas
+}
+
+// itemSchema returns the schema for the array element at the index, or nil.
+func (s *Schema) itemSchema(index int) *Schema {
+	if index < len(s.PrefixItems) {
+		return s.PrefixItems[index]
+	}
+	return s.Items
+}
@@ -0,0 +1,362 @@
+// Package schema validates JSON documents parsed by jsonx against JSON Schemas,
+// reporting errors with the location of the offending values in the document.
+//
+// It supports the keywords of JSON Schema draft-07 and 2020-12 that describe
+// the structure of values, and the annotations used by editors (such as title
+// and description). Only local references ($ref to a JSON pointer in the same
+// schema document) are supported.
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/sourcegraph/jsonx"
+)
+
+// A Schema is a JSON Schema. The boolean schemas true and false are represented as
+// a Schema with no keywords and as a Schema with Not set to a Schema with no
+// keywords, respectively.
+type Schema struct {
+	Dialect string `json:"$schema,omitempty"` // the JSON Schema version URI
+	ID      string `json:"$id,omitempty"`
+	Ref     string `json:"$ref,omitempty"` // a reference to another schema in the same document, applied alongside the other keywords
+
+	Defs        map[string]*Schema `json:"$defs,omitempty"`
+	Definitions map[string]*Schema `json:"definitions,omitempty"` // draft-07 name for $defs
+
+	// Annotations
+	Title               string            `json:"title,omitempty"`
+	Description         string            `json:"description,omitempty"`
+	MarkdownDescription string            `json:"markdownDescription,omitempty"` // the description in Markdown (a VS Code extension)
+	Default             json.RawMessage   `json:"default,omitempty"`
+	Examples            []json.RawMessage `json:"examples,omitempty"`
+
+	// Editor extensions (from VS Code)
+	DefaultSnippets          []DefaultSnippet `json:"defaultSnippets,omitempty"`          // values to suggest in completions
+	EnumDescriptions         []string         `json:"enumDescriptions,omitempty"`         // descriptions of the Enum values
+	MarkdownEnumDescriptions []string         `json:"markdownEnumDescriptions,omitempty"` // descriptions of the Enum values in Markdown
+
+	// Any value
+	Type  Types             `json:"type,omitempty"`
+	Enum  []json.RawMessage `json:"enum,omitempty"`
+	Const json.RawMessage   `json:"const,omitempty"`
+	AllOf []*Schema         `json:"allOf,omitempty"`
+	AnyOf []*Schema         `json:"anyOf,omitempty"`
+	OneOf []*Schema         `json:"oneOf,omitempty"`
+	Not   *Schema           `json:"not,omitempty"`
+	If    *Schema           `json:"if,omitempty"`
+	Then  *Schema           `json:"then,omitempty"`
+	Else  *Schema           `json:"else,omitempty"`
+
+	// Objects
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	PatternProperties    map[string]*Schema `json:"patternProperties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	MinProperties        *int               `json:"minProperties,omitempty"`
+	MaxProperties        *int               `json:"maxProperties,omitempty"`
+
+	// Arrays (the draft-07 array form of items and additionalItems are
+	// decoded as PrefixItems and Items)
+	PrefixItems []*Schema `json:"prefixItems,omitempty"`
+	Items       *Schema   `json:"-"`
+	MinItems    *int      `json:"minItems,omitempty"`
+	MaxItems    *int      `json:"maxItems,omitempty"`
+	UniqueItems bool      `json:"uniqueItems,omitempty"`
+
+	// Strings
+	MinLength *int   `json:"minLength,omitempty"`
+	MaxLength *int   `json:"maxLength,omitempty"`
+	Pattern   string `json:"pattern,omitempty"`
+
+	// Numbers (the draft-04 boolean form of exclusiveMinimum and
+	// exclusiveMaximum is decoded as the number form)
+	Minimum          *float64 `json:"minimum,omitempty"`
+	Maximum          *float64 `json:"maximum,omitempty"`
+	ExclusiveMinimum *float64 `json:"-"`
+	ExclusiveMaximum *float64 `json:"-"`
+	MultipleOf       *float64 `json:"multipleOf,omitempty"`
+
+	isFalse           bool           // whether this is the boolean schema false
+	ref               *Schema        // the resolved Ref
+	pattern           *regexp.Regexp // the compiled Pattern, or nil
+	patternProperties []patternProperty
+}
+
+type patternProperty struct {
+	pattern *regexp.Regexp // nil if the pattern is not supported
+	schema  *Schema
+}
+
+// A DefaultSnippet is a value to suggest in completions. In the Body, strings
+// starting with "^" are inserted without quotes, and other strings may contain
+// snippet placeholders (such as "${1:name}").
+type DefaultSnippet struct {
+	Label               string          `json:"label,omitempty"`
+	Description         string          `json:"description,omitempty"`
+	MarkdownDescription string          `json:"markdownDescription,omitempty"`
+	Body                json.RawMessage `json:"body,omitempty"`
+	BodyText            string          `json:"bodyText,omitempty"` // the snippet text to insert, if there is no Body
+}
+
+// Types is the list of JSON types allowed by a schema ("object", "array",
+// "string", "number", "integer", "boolean" or "null"). In JSON, it is a string or
+// an array of strings.
+type Types []string
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (t *Types) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		*t = Types{name}
+		return nil
+	}
+	var names []string
+	if err := json.Unmarshal(data, &names); err != nil {
+		return fmt.Errorf("invalid type: %s", data)
+	}
+	*t = names
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler.
+func (t Types) MarshalJSON() ([]byte, error) {
+	if len(t) == 1 {
+		return json.Marshal(t[0])
+	}
+	return json.Marshal([]string(t))
+}
+
+// MarshalJSON implements json.Marshaler. Items, ExclusiveMinimum and
+// ExclusiveMaximum are encoded in their 2020-12 form.
+func (s Schema) MarshalJSON() ([]byte, error) {
+	if s.isFalse {
+		return []byte("false"), nil
+	}
+	type plainSchema Schema // without the MarshalJSON method
+	return json.Marshal(struct {
+		plainSchema
+		Items            *Schema  `json:"items,omitempty"`
+		ExclusiveMinimum *float64 `json:"exclusiveMinimum,omitempty"`
+		ExclusiveMaximum *float64 `json:"exclusiveMaximum,omitempty"`
+	}{plainSchema(s), s.Items, s.ExclusiveMinimum, s.ExclusiveMaximum})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (s *Schema) UnmarshalJSON(data []byte) error {
+	switch strings.TrimSpace(string(data)) {
+	case "true":
+		*s = Schema{}
+		return nil
+	case "false":
+		*s = Schema{Not: &Schema{}, isFalse: true}
+		return nil
+	}
+
+	type plainSchema Schema // without the UnmarshalJSON method
+	var plain plainSchema
+	if err := json.Unmarshal(data, &plain); err != nil {
+		return err
+	}
+	*s = Schema(plain)
+
+	// Decode the keywords whose form differs between versions.
+	var keywords struct {
+		Items            json.RawMessage `json:"items"`
+		AdditionalItems  *Schema         `json:"additionalItems"`
+		ExclusiveMinimum json.RawMessage `json:"exclusiveMinimum"`
+		ExclusiveMaximum json.RawMessage `json:"exclusiveMaximum"`
+	}
+	if err := json.Unmarshal(data, &keywords); err != nil {
+		return err
+	}
+	if len(keywords.Items) > 0 {
+		if strings.HasPrefix(strings.TrimSpace(string(keywords.Items)), "[") {
+			if err := json.Unmarshal(keywords.Items, &s.PrefixItems); err != nil {
+				return err
+			}
+			s.Items = keywords.AdditionalItems
+		} else if err := json.Unmarshal(keywords.Items, &s.Items); err != nil {
+			return err
+		}
+	}
+	var err error
+	if s.ExclusiveMinimum, s.Minimum, err = exclusiveLimit(keywords.ExclusiveMinimum, s.Minimum); err != nil {
+		return err
+	}
+	if s.ExclusiveMaximum, s.Maximum, err = exclusiveLimit(keywords.ExclusiveMaximum, s.Maximum); err != nil {
+		return err
+	}
+	return nil
+}
+
+// exclusiveLimit decodes exclusiveMinimum or exclusiveMaximum, and returns the
+// exclusive and inclusive limits.
+func exclusiveLimit(data json.RawMessage, inclusive *float64) (*float64, *float64, error) {
+	switch strings.TrimSpace(string(data)) {
+	case "":
+		return nil, inclusive, nil
+	case "true":
+		return inclusive, nil, nil // draft-04
+	case "false":
+		return nil, inclusive, nil
+	}
+	var limit float64
+	if err := json.Unmarshal(data, &limit); err != nil {
+		return nil, nil, err
+	}
+	return &limit, inclusive, nil
+}
+
+var parseOptions = jsonx.ParseOptions{Comments: true, TrailingCommas: true}
+
+// Parse parses a JSON Schema document, which may contain comments and trailing
+// commas, and resolves its references.
+//
+// Patterns that are not supported by Go regular expressions (such as those with
+// lookarounds) are ignored. If one of the patternProperties of a schema is not
+// supported, its additionalProperties are not checked.
+func Parse(text string) (*Schema, error) {
+	data, errors := jsonx.ParseWithDetailedErrors(text, parseOptions)
+	if len(errors) > 0 {
+		return nil, fmt.Errorf("invalid schema: %s", errors[0].Code)
+	}
+	var s Schema
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	r := &resolver{root: &s, doc: doc, resolved: map[string]*Schema{"": &s}, prepared: map[*Schema]bool{}}
+	if err := r.prepare(&s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// A resolver resolves the references in a schema document.
+type resolver struct {
+	root     *Schema
+	doc      interface{}        // the decoded schema document
+	resolved map[string]*Schema // by JSON pointer
+	prepared map[*Schema]bool
+}
+
+// prepare resolves the references and compiles the patterns of the schema and its
+// subschemas.
+func (r *resolver) prepare(s *Schema) error {
+	if s == nil || r.prepared[s] {
+		return nil
+	}
+	r.prepared[s] = true
+
+	if s.Ref != "" {
+		ref, err := r.resolve(s.Ref)
+		if err != nil {
+			return err
+		}
+		s.ref = ref
+	}
+	if s.Pattern != "" {
+		s.pattern, _ = regexp.Compile(s.Pattern)
+	}
+	s.patternProperties = nil
+	for _, pattern := range sortedKeys(s.PatternProperties) {
+		re, _ := regexp.Compile(pattern)
+		s.patternProperties = append(s.patternProperties, patternProperty{pattern: re, schema: s.PatternProperties[pattern]})
+	}
+
+	for _, sub := range s.subschemas() {
+		if err := r.prepare(sub); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolve returns the schema that the reference refers to.
+func (r *resolver) resolve(ref string) (*Schema, error) {
+	i := strings.Index(ref, "#")
+	if i < 0 {
+		i = len(ref)
+	}
+	if base := ref[:i]; base != "" && base != strings.TrimSuffix(r.root.ID, "#") {
+		return nil, fmt.Errorf("unsupported $ref %q: only references within the schema are supported", ref)
+	}
+	fragment := strings.TrimPrefix(ref[i:], "#")
+	if s, ok := r.resolved[fragment]; ok {
+		return s, nil
+	}
+	if !strings.HasPrefix(fragment, "/") {
+		return nil, fmt.Errorf("unsupported $ref %q: only JSON pointer fragments are supported", ref)
+	}
+
+	value := r.doc
+	for _, token := range strings.Split(fragment[1:], "/") {
+		token, err := url.PathUnescape(token)
+		if err != nil {
+			return nil, fmt.Errorf("invalid $ref %q: %w", ref, err)
+		}
+		token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+		switch v := value.(type) {
+		case map[string]interface{}:
+			value = v[token]
+		case []interface{}:
+			var index int
+			if _, err := fmt.Sscanf(token, "%d", &index); err != nil || index < 0 || index >= len(v) {
+				return nil, fmt.Errorf("unresolvable $ref %q", ref)
+			}
+			value = v[index]
+		default:
+			value = nil
+		}
+		if value == nil {
+			return nil, fmt.Errorf("unresolvable $ref %q", ref)
+		}
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var s Schema
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("invalid schema at $ref %q: %w", ref, err)
+	}
+	r.resolved[fragment] = &s
+	if err := r.prepare(&s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// subschemas returns the schemas directly contained in the schema.
+func (s *Schema) subschemas() []*Schema {
+	var subschemas []*Schema
+	for _, m := range []map[string]*Schema{s.Defs, s.Definitions, s.Properties, s.PatternProperties} {
+		for _, key := range sortedKeys(m) {
+			subschemas = append(subschemas, m[key])
+		}
+	}
+	subschemas = append(subschemas, s.AllOf...)
+	subschemas = append(subschemas, s.AnyOf...)
+	subschemas = append(subschemas, s.OneOf...)
+	subschemas = append(subschemas, s.PrefixItems...)
+	return append(subschemas, s.Not, s.If, s.Then, s.Else, s.AdditionalProperties, s.Items)
+}
+
+func sortedKeys(m map[string]*Schema) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,153 @@
+package schema
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	t.Run("keywords", func(t *testing.T) {
+		s, err := Parse(`{
+			// comments and trailing commas are allowed
+			"$schema": "https://json-schema.org/draft/2020-12/schema",
+			"type": ["object", "null"],
+			"properties": {
+				"a": {"type": "string", "pattern": "^a"},
+			},
+			"prefixItems": [true],
+			"items": false,
+			"exclusiveMinimum": 1,
+		}`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := strings.Join(s.Type, ","), "object,null"; got != want {
+			t.Errorf("got type %q, want %q", got, want)
+		}
+		if a := s.Properties["a"]; a == nil || a.pattern == nil {
+			t.Errorf("got property a %+v, want a compiled pattern", a)
+		}
+		if len(s.PrefixItems) != 1 || s.PrefixItems[0].isFalse {
+			t.Errorf("got prefixItems %+v, want [true]", s.PrefixItems)
+		}
+		if s.Items == nil || !s.Items.isFalse {
+			t.Errorf("got items %+v, want false", s.Items)
+		}
+		if s.ExclusiveMinimum == nil || *s.ExclusiveMinimum != 1 {
+			t.Errorf("got exclusiveMinimum %v, want 1", s.ExclusiveMinimum)
+		}
+	})
+
+	t.Run("draft-07 items", func(t *testing.T) {
+		s, err := Parse(`{"items": [{"type": "string"}, {"type": "number"}], "additionalItems": false}`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(s.PrefixItems) != 2 {
+			t.Errorf("got %d prefixItems, want 2", len(s.PrefixItems))
+		}
+		if s.Items == nil || !s.Items.isFalse {
+			t.Errorf("got items %+v, want false", s.Items)
+		}
+	})
+
+	t.Run("draft-04 exclusive limits", func(t *testing.T) {
+		s, err := Parse(`{"minimum": 1, "exclusiveMinimum": true, "maximum": 2, "exclusiveMaximum": false}`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s.Minimum != nil || s.ExclusiveMinimum == nil || *s.ExclusiveMinimum != 1 {
+			t.Errorf("got minimum %v and exclusiveMinimum %v, want nil and 1", s.Minimum, s.ExclusiveMinimum)
+		}
+		if s.Maximum == nil || *s.Maximum != 2 || s.ExclusiveMaximum != nil {
+			t.Errorf("got maximum %v and exclusiveMaximum %v, want 2 and nil", s.Maximum, s.ExclusiveMaximum)
+		}
+	})
+
+	t.Run("refs", func(t *testing.T) {
+		s, err := Parse(`{
+			"$id": "https://example.com/schema.json",
+			"properties": {
+				"a": {"$ref": "#/$defs/a"},
+				"b": {"$ref": "#/definitions/b~1c"},
+				"c": {"$ref": "https://example.com/schema.json#/properties/a"},
+				"d": {"$ref": "#"},
+				"e": {"$ref": "#/properties/e/items/0", "items": [{"type": "null"}]},
+			},
+			"$defs": {"a": {"type": "string"}},
+			"definitions": {"b/c": {"type": "number"}},
+		}`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := s.Properties["a"].ref; got == nil || got.Type[0] != "string" {
+			t.Errorf("got $ref %+v for a, want the string schema", got)
+		}
+		if got := s.Properties["b"].ref; got == nil || got.Type[0] != "number" {
+			t.Errorf("got $ref %+v for b, want the number schema", got)
+		}
+		if got := s.Properties["c"].ref; got == nil || got.ref == nil || got.ref.Type[0] != "string" {
+			t.Errorf("got $ref %+v for c, want the schema of a", got)
+		}
+		if got := s.Properties["d"].ref; got != s {
+			t.Errorf("got $ref %p for d, want the root schema %p", got, s)
+		}
+		if got := s.Properties["e"].ref; got == nil || got.Type[0] != "null" {
+			t.Errorf("got $ref %+v for e, want the null schema", got)
+		}
+	})
+
+	t.Run("unsupported pattern", func(t *testing.T) {
+		s, err := Parse(`{"pattern": "(?=a)"}`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s.pattern != nil {
+			t.Errorf("got pattern %v, want nil", s.pattern)
+		}
+	})
+
+	errorTests := map[string]struct {
+		input string
+		want  string
+	}{
+		"syntax error":      {input: `{"type": }`, want: "invalid schema: ValueExpected"},
+		"invalid type":      {input: `{"type": 1}`, want: "invalid schema: invalid type: 1"},
+		"remote ref":        {input: `{"$ref": "other.json#/a"}`, want: `unsupported $ref "other.json#/a": only references within the schema are supported`},
+		"anchor ref":        {input: `{"$ref": "#a"}`, want: `unsupported $ref "#a": only JSON pointer fragments are supported`},
+		"unresolvable ref":  {input: `{"$ref": "#/$defs/a"}`, want: `unresolvable $ref "#/$defs/a"`},
+		"invalid ref":       {input: `{"$ref": "#/%zz"}`, want: `invalid $ref "#/%zz": invalid URL escape "%zz"`},
+		"invalid ref value": {input: `{"$ref": "#/title", "title": "a"}`, want: `invalid schema at $ref "#/title": json: cannot unmarshal string into Go value of type schema.plainSchema`},
+	}
+	for name, test := range errorTests {
+		t.Run(name, func(t *testing.T) {
+			_, err := Parse(test.input)
+			if err == nil {
+				t.Fatalf("got no error, want %q", test.want)
+			}
+			if err.Error() != test.want {
+				t.Errorf("got error %q, want %q", err, test.want)
+			}
+		})
+	}
+}
+
+func TestSchema_MarshalJSON(t *testing.T) {
+	s, err := Parse(`{
+		"type": "array",
+		"items": [{"type": ["string", "null"]}],
+		"additionalItems": false,
+		"exclusiveMaximum": 2,
+	}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(data), `{"type":"array","prefixItems":[{"type":["string","null"]}],"items":false,"exclusiveMaximum":2}`; got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
@@ -0,0 +1,433 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/sourcegraph/jsonx"
+)
+
+// A ValidationError describes a value in a JSON document that does not conform to
+// a schema.
+type ValidationError struct {
+	Path    jsonx.Path // the key path of the value
+	Offset  int        // character offset of the range to report in the document
+	Length  int        // the length (in characters) of the range to report
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Message)
+}
+
+// Validate validates the JSON document with the parse tree root (obtained from
+// jsonx.ParseTree) against the schema, and returns the errors for the values that
+// do not conform to it.
+//
+// Errors are reported at the range of the offending value, except that a missing
+// required property is reported at the key of the object's property (or at the
+// object's opening brace for the root object), and a property that is not allowed
+// is reported at its key. When none of the subschemas of anyOf or oneOf match, the
+// errors of the subschema that matches most closely are reported.
+func (s *Schema) Validate(root *jsonx.Node) []ValidationError {
+	if root == nil {
+		return nil
+	}
+	v := newValidator()
+	v.validate(s, root, nil)
+	return v.errors
+}
+
+type validator struct {
+	errors []ValidationError
+	active map[activeKey]bool // the schemas being applied to the nodes, to stop recursive $refs
+}
+
+func newValidator() *validator {
+	return &validator{active: map[activeKey]bool{}}
+}
+
+type activeKey struct {
+	schema *Schema
+	node   *jsonx.Node
+}
+
+// try validates the node against the schema and returns the errors, without
+// reporting them.
+func (v *validator) try(s *Schema, node *jsonx.Node, path jsonx.Path) []ValidationError {
+	saved := v.errors
+	v.errors = nil
+	v.validate(s, node, path)
+	errors := v.errors
+	v.errors = saved
+	return errors
+}
+
+func (v *validator) addError(node *jsonx.Node, path jsonx.Path, format string, args ...interface{}) {
+	v.addErrorAt(node.Offset, node.Length, path, format, args...)
+}
+
+func (v *validator) addErrorAt(offset, length int, path jsonx.Path, format string, args ...interface{}) {
+	v.errors = append(v.errors, ValidationError{Path: path, Offset: offset, Length: length, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) validate(s *Schema, node *jsonx.Node, path jsonx.Path) {
+	if s == nil || v.active[activeKey{s, node}] {
+		return
+	}
+	v.active[activeKey{s, node}] = true
+	defer delete(v.active, activeKey{s, node})
+
+	if s.isFalse {
+		v.addError(node, path, "Value is not allowed.")
+		return
+	}
+	if s.ref != nil {
+		v.validate(s.ref, node, path)
+	}
+
+	if len(s.Type) > 0 && !matchesType(node, s.Type) {
+		if len(s.Type) == 1 {
+			v.addError(node, path, "Incorrect type. Expected %q.", s.Type[0])
+		} else {
+			v.addError(node, path, "Incorrect type. Expected one of %s.", strings.Join(s.Type, ", "))
+		}
+	}
+
+	for _, sub := range s.AllOf {
+		v.validate(sub, node, path)
+	}
+	if s.Not != nil && len(v.try(s.Not, node, path)) == 0 {
+		v.addError(node, path, "Matches a schema that is not allowed.")
+	}
+	if len(s.AnyOf) > 0 {
+		if matches, best := v.match(s.AnyOf, node, path); matches == 0 {
+			v.errors = append(v.errors, best...)
+		}
+	}
+	if len(s.OneOf) > 0 {
+		if matches, best := v.match(s.OneOf, node, path); matches == 0 {
+			v.errors = append(v.errors, best...)
+		} else if matches > 1 {
+			v.addErrorAt(node.Offset, 1, path, "Matches multiple schemas when only one must validate.")
+		}
+	}
+	if s.If != nil {
+		if len(v.try(s.If, node, path)) == 0 {
+			v.validate(s.Then, node, path)
+		} else {
+			v.validate(s.Else, node, path)
+		}
+	}
+
+	if len(s.Enum) > 0 {
+		value := jsonx.NodeValue(*node)
+		found := false
+		values := make([]string, len(s.Enum))
+		for i, enumValue := range s.Enum {
+			found = found || equal(value, decode(enumValue))
+			values[i] = compact(enumValue)
+		}
+		if !found {
+			v.addError(node, path, "Value is not accepted. Valid values: %s.", strings.Join(values, ", "))
+		}
+	}
+	if len(s.Const) > 0 && !equal(jsonx.NodeValue(*node), decode(s.Const)) {
+		v.addError(node, path, "Value must be %s.", compact(s.Const))
+	}
+
+	switch node.Type {
+	case jsonx.Object:
+		v.validateObject(s, node, path)
+	case jsonx.Array:
+		v.validateArray(s, node, path)
+	case jsonx.String:
+		v.validateString(s, node, path)
+	case jsonx.Number:
+		v.validateNumber(s, node, path)
+	}
+}
+
+// match validates the node against each of the schemas, and returns the number of
+// schemas that it matches and the errors of the schema that matches most closely.
+func (v *validator) match(schemas []*Schema, node *jsonx.Node, path jsonx.Path) (matches int, best []ValidationError) {
+	for _, sub := range schemas {
+		errors := v.try(sub, node, path)
+		if len(errors) == 0 {
+			matches++
+		} else if best == nil || len(errors) < len(best) {
+			best = errors
+		}
+	}
+	return matches, best
+}
+
+func (v *validator) validateObject(s *Schema, node *jsonx.Node, path jsonx.Path) {
+	properties := map[string]*jsonx.Node{} // by name, nil for a property without a value
+	for _, property := range node.Children {
+		key := property.Children[0]
+		name := key.Value.(string)
+		var value *jsonx.Node
+		if len(property.Children) >= 2 {
+			value = property.Children[1]
+		}
+		properties[name] = value
+		propertyPath := appendPath(path, jsonx.Segment{IsProperty: true, Property: name})
+
+		for _, sub := range s.propertySchemas(name) {
+			if sub.isFalse {
+				v.addError(key, propertyPath, "Property %s is not allowed.", name)
+				break
+			}
+			if value != nil {
+				v.validate(sub, value, propertyPath)
+			}
+		}
+	}
+
+	for _, name := range s.Required {
+		if properties[name] == nil {
+			if node.Parent != nil && node.Parent.Type == jsonx.Property {
+				v.addError(node.Parent.Children[0], path, "Missing property %q.", name)
+			} else {
+				v.addErrorAt(node.Offset, 1, path, "Missing property %q.", name)
+			}
+		}
+	}
+	if s.MaxProperties != nil && len(node.Children) > *s.MaxProperties {
+		v.addError(node, path, "Object has more properties than limit of %d.", *s.MaxProperties)
+	}
+	if s.MinProperties != nil && len(node.Children) < *s.MinProperties {
+		v.addError(node, path, "Object has fewer properties than the required number of %d.", *s.MinProperties)
+	}
+}
+
+func (v *validator) validateArray(s *Schema, node *jsonx.Node, path jsonx.Path) {
+	for i, item := range node.Children {
+		sub := s.itemSchema(i)
+		if i >= len(s.PrefixItems) && sub != nil && sub.isFalse && len(s.PrefixItems) > 0 {
+			v.addError(node, path, "Array has too many items according to schema. Expected %d or fewer.", len(s.PrefixItems))
+			break
+		}
+		v.validate(sub, item, appendPath(path, jsonx.Segment{Index: i}))
+	}
+
+	if s.MinItems != nil && len(node.Children) < *s.MinItems {
+		v.addError(node, path, "Array has too few items. Expected %d or more.", *s.MinItems)
+	}
+	if s.MaxItems != nil && len(node.Children) > *s.MaxItems {
+		v.addError(node, path, "Array has too many items. Expected %d or fewer.", *s.MaxItems)
+	}
+	if s.UniqueItems {
+		values := make([]interface{}, len(node.Children))
+		for i, item := range node.Children {
+			values[i] = jsonx.NodeValue(*item)
+		}
+	outer:
+		for i := range values {
+			for j := 0; j < i; j++ {
+				if equal(values[i], values[j]) {
+					v.addError(node, path, "Array has duplicate items.")
+					break outer
+				}
+			}
+		}
+	}
+}
+
+func (v *validator) validateString(s *Schema, node *jsonx.Node, path jsonx.Path) {
+	value := node.Value.(string)
+	length := utf8.RuneCountInString(value)
+	if s.MinLength != nil && length < *s.MinLength {
+		v.addError(node, path, "String is shorter than the minimum length of %d.", *s.MinLength)
+	}
+	if s.MaxLength != nil && length > *s.MaxLength {
+		v.addError(node, path, "String is longer than the maximum length of %d.", *s.MaxLength)
+	}
+	if s.pattern != nil && !s.pattern.MatchString(value) {
+		v.addError(node, path, "String does not match the pattern of %q.", s.Pattern)
+	}
+}
+
+func (v *validator) validateNumber(s *Schema, node *jsonx.Node, path jsonx.Path) {
+	value, err := strconv.ParseFloat(string(node.Value.(json.Number)), 64)
+	if err != nil {
+		return
+	}
+	if s.MultipleOf != nil && *s.MultipleOf > 0 && !isMultiple(node.Value.(json.Number), *s.MultipleOf) {
+		v.addError(node, path, "Value is not divisible by %s.", formatNumber(*s.MultipleOf))
+	}
+	if s.ExclusiveMinimum != nil && value <= *s.ExclusiveMinimum {
+		v.addError(node, path, "Value is below the exclusive minimum of %s.", formatNumber(*s.ExclusiveMinimum))
+	}
+	if s.ExclusiveMaximum != nil && value >= *s.ExclusiveMaximum {
+		v.addError(node, path, "Value is above the exclusive maximum of %s.", formatNumber(*s.ExclusiveMaximum))
+	}
+	if s.Minimum != nil && value < *s.Minimum {
+		v.addError(node, path, "Value is below the minimum of %s.", formatNumber(*s.Minimum))
+	}
+	if s.Maximum != nil && value > *s.Maximum {
+		v.addError(node, path, "Value is above the maximum of %s.", formatNumber(*s.Maximum))
+	}
+}
+
+// matchesType reports whether the node's value has one of the JSON Schema types.
+func matchesType(node *jsonx.Node, types []string) bool {
+	for _, t := range types {
+		switch t {
+		case "object":
+			if node.Type == jsonx.Object {
+				return true
+			}
+		case "array":
+			if node.Type == jsonx.Array {
+				return true
+			}
+		case "string":
+			if node.Type == jsonx.String {
+				return true
+			}
+		case "number":
+			if node.Type == jsonx.Number {
+				return true
+			}
+		case "integer":
+			if node.Type == jsonx.Number && isInteger(node.Value.(json.Number)) {
+				return true
+			}
+		case "boolean":
+			if node.Type == jsonx.Boolean {
+				return true
+			}
+		case "null":
+			if node.Type == jsonx.Null {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// maxExactLength is the maximum length of the numbers whose exact values are used to
+// compare them (to avoid the cost of the exact value of a number like 1e999999999).
+// Longer numbers, and those with larger exponents, are compared as float64s.
+const maxExactLength = 1000
+
+// exact returns the exact value of the number, or false if it is invalid or too
+// long (see maxExactLength).
+func exact(n json.Number) (*big.Rat, bool) {
+	s := string(n)
+	if len(s) > maxExactLength {
+		return nil, false
+	}
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		if exp, err := strconv.Atoi(s[i+1:]); err != nil || exp > maxExactLength || exp < -maxExactLength {
+			return nil, false
+		}
+	}
+	return new(big.Rat).SetString(s)
+}
+
+// float returns the number as a float64 (rounded to infinity or zero if it is out of
+// range).
+func float(n json.Number) float64 {
+	f, _ := strconv.ParseFloat(string(n), 64)
+	return f
+}
+
+func isInteger(n json.Number) bool {
+	if r, ok := exact(n); ok {
+		return r.IsInt()
+	}
+	f := float(n)
+	return f == math.Trunc(f)
+}
+
+// isMultiple reports whether the number is a multiple of the divisor. It divides the
+// exact values, so that a decimal divisor like 0.1 has no rounding error.
+func isMultiple(n json.Number, divisor float64) bool {
+	r, ok := exact(n)
+	d, okD := exact(json.Number(strconv.FormatFloat(divisor, 'g', -1, 64)))
+	if !ok || !okD {
+		q := float(n) / divisor
+		return q == math.Trunc(q)
+	}
+	return r.Quo(r, d).IsInt()
+}
+
+// equal reports whether the decoded JSON values are equal. Numbers are equal if
+// they have the same numeric value.
+func equal(a, b interface{}) bool {
+	switch a := a.(type) {
+	case map[string]interface{}:
+		b, ok := b.(map[string]interface{})
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for key, value := range a {
+			other, ok := b[key]
+			if !ok || !equal(value, other) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		b, ok := b.([]interface{})
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if !equal(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+	case json.Number:
+		b, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		ra, okA := exact(a)
+		rb, okB := exact(b)
+		if !okA || !okB {
+			return float(a) == float(b)
+		}
+		return ra.Cmp(rb) == 0
+	default:
+		return a == b
+	}
+}
+
+// decode decodes the JSON value, with numbers decoded as json.Number.
+func decode(data json.RawMessage) interface{} {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var value interface{}
+	_ = dec.Decode(&value)
+	return value
+}
+
+func compact(data json.RawMessage) string {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, data); err != nil {
+		return string(data)
+	}
+	return buf.String()
+}
+
+func formatNumber(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// appendPath returns a new path consisting of the path followed by the segment.
+func appendPath(path jsonx.Path, segment jsonx.Segment) jsonx.Path {
+	p := make(jsonx.Path, len(path), len(path)+1)
+	copy(p, path)
+	return append(p, segment)
+}
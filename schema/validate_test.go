@@ -0,0 +1,229 @@
+package schema
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sourcegraph/jsonx"
+)
+
+func TestValidate(t *testing.T) {
+	tests := map[string]struct {
+		schema string
+		input  string
+		want   []ValidationError
+	}{
+		"empty document": {
+			schema: `{"type": "object"}`,
+			input:  "",
+		},
+		"true schema": {
+			schema: `true`,
+			input:  `{"a": [1]}`,
+		},
+		"false schema": {
+			schema: `false`,
+			input:  `1`,
+			want:   []ValidationError{{Offset: 0, Length: 1, Message: "Value is not allowed."}},
+		},
+		"type": {
+			schema: `{"properties": {"a": {"type": "string"}, "b": {"type": ["number", "null"]}, "c": {"type": "integer"}, "d": {"type": "integer"}}}`,
+			input:  `{"a": 1, "b": "x", "c": 1.0, "d": 1.5}`,
+			want: []ValidationError{
+				{Path: jsonx.PropertyPath("a"), Offset: 6, Length: 1, Message: `Incorrect type. Expected "string".`},
+				{Path: jsonx.PropertyPath("b"), Offset: 14, Length: 3, Message: "Incorrect type. Expected one of number, null."},
+				{Path: jsonx.PropertyPath("d"), Offset: 34, Length: 3, Message: `Incorrect type. Expected "integer".`},
+			},
+		},
+		"enum and const": {
+			schema: `{"items": [{"enum": ["a", 1, {"b": [true]}]}, {"enum": ["a", 1]}, {"const": 10}, {"const": 10}]}`,
+			input:  `[{"b": [true]}, 2, 1e1, 11]`,
+			want: []ValidationError{
+				{Path: jsonx.MakePath(1), Offset: 16, Length: 1, Message: `Value is not accepted. Valid values: "a", 1.`},
+				{Path: jsonx.MakePath(3), Offset: 24, Length: 2, Message: "Value must be 10."},
+			},
+		},
+		"required": {
+			schema: `{"required": ["a", "b"], "properties": {"c": {"required": ["d"]}}}`,
+			input:  `{"a":, "c": {}}`,
+			want: []ValidationError{
+				{Path: jsonx.PropertyPath("c"), Offset: 7, Length: 3, Message: `Missing property "d".`},
+				{Offset: 0, Length: 1, Message: `Missing property "a".`},
+				{Offset: 0, Length: 1, Message: `Missing property "b".`},
+			},
+		},
+		"additional properties": {
+			schema: `{
+				"properties": {"a": true, "b": false},
+				"patternProperties": {"^x-": {"type": "string"}},
+				"additionalProperties": false,
+			}`,
+			input: `{"a": 1, "b": 2, "x-c": 3, "d": 4}`,
+			want: []ValidationError{
+				{Path: jsonx.PropertyPath("b"), Offset: 9, Length: 3, Message: "Property b is not allowed."},
+				{Path: jsonx.PropertyPath("x-c"), Offset: 24, Length: 1, Message: `Incorrect type. Expected "string".`},
+				{Path: jsonx.PropertyPath("d"), Offset: 27, Length: 3, Message: "Property d is not allowed."},
+			},
+		},
+		"additional properties schema": {
+			schema: `{"properties": {"a": true}, "additionalProperties": {"type": "number"}}`,
+			input:  `{"a": "x", "b": "y"}`,
+			want: []ValidationError{
+				{Path: jsonx.PropertyPath("b"), Offset: 16, Length: 3, Message: `Incorrect type. Expected "number".`},
+			},
+		},
+		"unsupported pattern properties": {
+			schema: `{"patternProperties": {"(?!x)": true}, "additionalProperties": false}`,
+			input:  `{"a": 1}`,
+		},
+		"object size": {
+			schema: `{"items": [{"minProperties": 2}, {"maxProperties": 1}]}`,
+			input:  `[{"a": 1}, {"a": 1, "b": 2}]`,
+			want: []ValidationError{
+				{Path: jsonx.MakePath(0), Offset: 1, Length: 8, Message: "Object has fewer properties than the required number of 2."},
+				{Path: jsonx.MakePath(1), Offset: 11, Length: 16, Message: "Object has more properties than limit of 1."},
+			},
+		},
+		"arrays": {
+			schema: `{"properties": {
+				"a": {"items": {"type": "number"}, "minItems": 3},
+				"b": {"prefixItems": [{"type": "string"}], "items": false, "maxItems": 1},
+				"c": {"uniqueItems": true},
+			}}`,
+			input: `{"a": [1, "x"], "b": [1, 2], "c": [{"d": 1.0}, {"d": 1}]}`,
+			want: []ValidationError{
+				{Path: jsonx.MakePath("a", 1), Offset: 10, Length: 3, Message: `Incorrect type. Expected "number".`},
+				{Path: jsonx.PropertyPath("a"), Offset: 6, Length: 8, Message: "Array has too few items. Expected 3 or more."},
+				{Path: jsonx.MakePath("b", 0), Offset: 22, Length: 1, Message: `Incorrect type. Expected "string".`},
+				{Path: jsonx.PropertyPath("b"), Offset: 21, Length: 6, Message: "Array has too many items according to schema. Expected 1 or fewer."},
+				{Path: jsonx.PropertyPath("b"), Offset: 21, Length: 6, Message: "Array has too many items. Expected 1 or fewer."},
+				{Path: jsonx.PropertyPath("c"), Offset: 34, Length: 22, Message: "Array has duplicate items."},
+			},
+		},
+		"strings": {
+			schema: `{"items": {"minLength": 2, "maxLength": 3, "pattern": "^[a-z😀]+$"}}`,
+			input:  `["a", "😀😀", "abcd", "A1"]`,
+			want: []ValidationError{
+				{Path: jsonx.MakePath(0), Offset: 1, Length: 3, Message: "String is shorter than the minimum length of 2."},
+				{Path: jsonx.MakePath(2), Offset: 12, Length: 6, Message: "String is longer than the maximum length of 3."},
+				{Path: jsonx.MakePath(3), Offset: 20, Length: 4, Message: `String does not match the pattern of "^[a-z😀]+$".`},
+			},
+		},
+		"numbers": {
+			schema: `{"properties": {
+				"a": {"minimum": 1, "maximum": 2},
+				"b": {"minimum": 1, "maximum": 2},
+				"c": {"exclusiveMinimum": 1, "exclusiveMaximum": 2},
+				"d": {"exclusiveMinimum": 1, "exclusiveMaximum": 2},
+				"e": {"multipleOf": 0.5},
+				"f": {"multipleOf": 0.5},
+				"g": {"multipleOf": 0.1},
+				"h": {"multipleOf": 0.1},
+			}}`,
+			input: `{"a": 0, "b": 3, "c": 1, "d": 2, "e": 1.5, "f": 1.2, "g": 0.3, "h": 0.35}`,
+			want: []ValidationError{
+				{Path: jsonx.PropertyPath("a"), Offset: 6, Length: 1, Message: "Value is below the minimum of 1."},
+				{Path: jsonx.PropertyPath("b"), Offset: 14, Length: 1, Message: "Value is above the maximum of 2."},
+				{Path: jsonx.PropertyPath("c"), Offset: 22, Length: 1, Message: "Value is below the exclusive minimum of 1."},
+				{Path: jsonx.PropertyPath("d"), Offset: 30, Length: 1, Message: "Value is above the exclusive maximum of 2."},
+				{Path: jsonx.PropertyPath("f"), Offset: 48, Length: 3, Message: "Value is not divisible by 0.5."},
+				{Path: jsonx.PropertyPath("h"), Offset: 68, Length: 4, Message: "Value is not divisible by 0.1."},
+			},
+		},
+		"large exponents": {
+			schema: `{"items": [{"type": "integer"}, {"multipleOf": 0.5}, {"enum": [1]}], "uniqueItems": true}`,
+			input:  `[1e999999999, 1E+999999999, 1e-999999999]`,
+			want: []ValidationError{
+				{Path: jsonx.MakePath(2), Offset: 28, Length: 12, Message: "Value is not accepted. Valid values: 1."},
+				{Offset: 0, Length: 41, Message: "Array has duplicate items."},
+			},
+		},
+		"refs": {
+			schema: `{
+				"$defs": {
+					"node": {
+						"type": "object",
+						"properties": {"name": {"type": "string"}, "children": {"items": {"$ref": "#/$defs/node"}}},
+					},
+				},
+				"$ref": "#/$defs/node",
+				"required": ["name"],
+			}`,
+			input: `{"name": "a", "children": [{"name": 1, "children": [{"name": "c"}, 2]}]}`,
+			want: []ValidationError{
+				{Path: jsonx.MakePath("children", 0, "name"), Offset: 36, Length: 1, Message: `Incorrect type. Expected "string".`},
+				{Path: jsonx.MakePath("children", 0, "children", 1), Offset: 67, Length: 1, Message: `Incorrect type. Expected "object".`},
+			},
+		},
+		"recursive ref": {
+			schema: `{"$ref": "#", "type": "string"}`,
+			input:  `1`,
+			want:   []ValidationError{{Offset: 0, Length: 1, Message: `Incorrect type. Expected "string".`}},
+		},
+		"all of": {
+			schema: `{"allOf": [{"type": "number"}, {"minimum": 2}]}`,
+			input:  `1`,
+			want:   []ValidationError{{Offset: 0, Length: 1, Message: "Value is below the minimum of 2."}},
+		},
+		"any of": {
+			schema: `{"items": {"anyOf": [{"type": "string", "minLength": 2}, {"type": "number"}]}}`,
+			input:  `["ab", 1, "a", true]`,
+			want: []ValidationError{
+				{Path: jsonx.MakePath(2), Offset: 10, Length: 3, Message: "String is shorter than the minimum length of 2."},
+				{Path: jsonx.MakePath(3), Offset: 15, Length: 4, Message: `Incorrect type. Expected "string".`},
+			},
+		},
+		"one of": {
+			schema: `{"items": {"oneOf": [{"type": "number"}, {"type": "integer"}]}}`,
+			input:  `[1.5, 1, null]`,
+			want: []ValidationError{
+				{Path: jsonx.MakePath(1), Offset: 6, Length: 1, Message: "Matches multiple schemas when only one must validate."},
+				{Path: jsonx.MakePath(2), Offset: 9, Length: 4, Message: `Incorrect type. Expected "number".`},
+			},
+		},
+		"not": {
+			schema: `{"items": {"not": {"type": "string"}}}`,
+			input:  `[1, "a"]`,
+			want: []ValidationError{
+				{Path: jsonx.MakePath(1), Offset: 4, Length: 3, Message: "Matches a schema that is not allowed."},
+			},
+		},
+		"if then else": {
+			schema: `{"items": {"if": {"type": "string"}, "then": {"minLength": 2}, "else": {"minimum": 2}}}`,
+			input:  `["a", 1, "ab", 2]`,
+			want: []ValidationError{
+				{Path: jsonx.MakePath(0), Offset: 1, Length: 3, Message: "String is shorter than the minimum length of 2."},
+				{Path: jsonx.MakePath(1), Offset: 6, Length: 1, Message: "Value is below the minimum of 2."},
+			},
+		},
+		"comments and trailing commas": {
+			schema: `{"properties": {"a": {"type": "string"}}}`,
+			input: `{
+	// comment
+	"a": 1,
+}`,
+			want: []ValidationError{
+				{Path: jsonx.PropertyPath("a"), Offset: 20, Length: 1, Message: `Incorrect type. Expected "string".`},
+			},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			s, err := Parse(test.schema)
+			if err != nil {
+				t.Fatal(err)
+			}
+			root, _ := jsonx.ParseTree(test.input, jsonx.ParseOptions{Comments: true, TrailingCommas: true})
+			if got := s.Validate(root); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got errors %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestValidationError(t *testing.T) {
+	err := &ValidationError{Path: jsonx.MakePath("a", 1), Message: "Value is not allowed."}
+	if got, want := err.Error(), `["a",1]: Value is not allowed.`; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
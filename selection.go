@@ -0,0 +1,75 @@
+// This file was ported from https://github.com/microsoft/vscode-json-languageservice/blob/main/src/services/jsonSelectionRanges.ts,
+// which is licensed as follows:
+//
+// Copyright (c) Microsoft Corporation. All rights reserved. Licensed under the MIT License.
+
+package jsonx
+
+// A SelectionRange is a range in a JSON document to select when expanding the
+// selection, along with the enclosing range to select next.
+type SelectionRange struct {
+	Range  Range           // the range to select
+	Parent *SelectionRange // the range that contains this range, or nil
+}
+
+// GetSelectionRanges returns, for each character offset in the JSON document, the
+// chain of nested ranges to select when expanding the selection from the offset,
+// starting with the innermost range. From the inside out, a chain consists of:
+//
+//   - the contents of the string, array or object at the offset (without the
+//     quotes or brackets) and the string, array or object itself, or the other
+//     value at the offset
+//   - for an object property, the property (key and value) and the property
+//     including the comma after it, if any
+//   - for an array element, the element including the comma after it, if any
+//   - the same ranges for each enclosing value
+//
+// If the offset is not in any value, the chain consists of the empty range at the
+// offset.
+//
+// Source: https://github.com/microsoft/vscode-json-languageservice/blob/main/src/services/jsonSelectionRanges.ts
+func GetSelectionRanges(text string, offsets []int) []SelectionRange {
+	root, _ := ParseTree(text, ParseOptions{Comments: true, TrailingCommas: true})
+	scanner := NewScanner(text, ScanOptions{Trivia: false})
+
+	// offsetAfterComma returns the offset after the comma that follows the offset,
+	// or -1 if the next token is not a comma.
+	offsetAfterComma := func(offset int) int {
+		scanner.SetPosition(offset)
+		if scanner.Scan() == CommaToken {
+			return scanner.TokenOffset() + scanner.TokenLength()
+		}
+		return -1
+	}
+
+	result := make([]SelectionRange, len(offsets))
+	for i, offset := range offsets {
+		var ranges []Range // from the inside out
+		for node := FindNodeAtOffset(root, offset, true); node != nil; node = node.Parent {
+			switch node.Type {
+			case String, Object, Array:
+				// the range without the quotes or brackets
+				contentStart, contentEnd := node.Offset+1, node.Offset+node.Length-1
+				if contentStart < contentEnd && offset >= contentStart && offset <= contentEnd {
+					ranges = append(ranges, Range{Offset: contentStart, Length: contentEnd - contentStart})
+				}
+			}
+			ranges = append(ranges, Range{Offset: node.Offset, Length: node.Length})
+			if node.Type == Property || node.Parent != nil && node.Parent.Type == Array {
+				if end := offsetAfterComma(node.Offset + node.Length); end != -1 {
+					ranges = append(ranges, Range{Offset: node.Offset, Length: end - node.Offset})
+				}
+			}
+		}
+		if len(ranges) == 0 {
+			ranges = append(ranges, Range{Offset: offset})
+		}
+
+		var parent *SelectionRange
+		for j := len(ranges) - 1; j > 0; j-- {
+			parent = &SelectionRange{Range: ranges[j], Parent: parent}
+		}
+		result[i] = SelectionRange{Range: ranges[0], Parent: parent}
+	}
+	return result
+}
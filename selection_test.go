@@ -0,0 +1,56 @@
+// This file was ported from https://github.com/microsoft/vscode-json-languageservice/blob/main/src/test/selectionRanges.test.ts,
+// which is licensed as follows:
+//
+// Copyright (c) Microsoft Corporation. All rights reserved. Licensed under the MIT License.
+
+package jsonx
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGetSelectionRanges(t *testing.T) {
+	// The offset is marked with "|", and each range is given as the text it selects.
+	tests := map[string][]string{
+		`|{}`: {`{}`},
+		`{|}`: {`{}`},
+		`{"a": "x|y"}`: {
+			`xy`, `"xy"`, `"a": "xy"`, `"a": "xy"`, `{"a": "xy"}`,
+		},
+		`{"a": 1, "b": |true}`: {
+			`true`, `"b": true`, `"a": 1, "b": true`, `{"a": 1, "b": true}`,
+		},
+		`{"a": 1|, "b": true}`: {
+			`1`, `"a": 1`, `"a": 1,`, `"a": 1, "b": true`, `{"a": 1, "b": true}`,
+		},
+		`{"|a": [1, 2]}`: {
+			`a`, `"a"`, `"a": [1, 2]`, `"a": [1, 2]`, `{"a": [1, 2]}`,
+		},
+		`[[1, |2] , 3]`: {
+			`2`, `1, 2`, `[1, 2]`, `[1, 2] ,`, `[1, 2] , 3`, `[[1, 2] , 3]`,
+		},
+		`[1, /* c */ |2]`: {
+			`2`, `1, /* c */ 2`, `[1, /* c */ 2]`,
+		},
+		`  |  `: {``},
+	}
+	for input, want := range tests {
+		t.Run(input, func(t *testing.T) {
+			offset := strings.Index(input, "|")
+			text := input[:offset] + input[offset+1:]
+			got := GetSelectionRanges(text, []int{offset})
+			if len(got) != 1 {
+				t.Fatalf("got %d selection ranges, want 1", len(got))
+			}
+			var ranges []string
+			for r := &got[0]; r != nil; r = r.Parent {
+				ranges = append(ranges, text[r.Range.Offset:r.Range.Offset+r.Range.Length])
+			}
+			if !reflect.DeepEqual(ranges, want) {
+				t.Errorf("got  %q\nwant %q", ranges, want)
+			}
+		})
+	}
+}
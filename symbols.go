@@ -0,0 +1,134 @@
+// This file was ported from https://github.com/microsoft/vscode-json-languageservice/blob/main/src/services/jsonDocumentSymbols.ts,
+// which is licensed as follows:
+//
+// Copyright (c) Microsoft Corporation. All rights reserved. Licensed under the MIT License.
+
+package jsonx
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// A DocumentSymbol is a property or array element in a JSON document, for outline
+// views and breadcrumbs.
+type DocumentSymbol struct {
+	Name           string           // the property name, or the array index
+	Detail         string           // the value as written in the document for literals, "[]" and "{}" for empty arrays and objects, and "" otherwise
+	Kind           NodeType         // the type of the value
+	Range          Range            // the range of the property (key and value), or of the array element
+	SelectionRange Range            // the range of the property's key, or of the array element
+	Children       []DocumentSymbol // the properties or elements of the value
+}
+
+// DocumentSymbolOptions specifies options for computing document symbols.
+type DocumentSymbolOptions struct {
+	// ResultLimit is the maximum number of symbols to return (0 for no limit). If
+	// there are more, the most deeply nested symbols are omitted.
+	ResultLimit int
+}
+
+// GetDocumentSymbols returns the hierarchy of symbols of the JSON document with the
+// parse tree root (obtained from ParseTree). Properties without a value are omitted.
+//
+// Source: https://github.com/microsoft/vscode-json-languageservice/blob/main/src/services/jsonDocumentSymbols.ts
+func GetDocumentSymbols(root *Node, text string, options DocumentSymbolOptions) []DocumentSymbol {
+	if root == nil {
+		return nil
+	}
+
+	// Select the symbols breadth-first, so that the result limit omits the most
+	// deeply nested symbols.
+	limit := options.ResultLimit
+	selected := map[*Node]bool{} // the properties and elements to include
+	queue := []*Node{root}
+	for len(queue) > 0 && (options.ResultLimit == 0 || limit > 0) {
+		node := queue[0]
+		queue = queue[1:]
+		for _, child := range node.Children {
+			if options.ResultLimit > 0 && limit == 0 {
+				break
+			}
+			value := symbolValue(node, child)
+			if value == nil {
+				continue
+			}
+			selected[child] = true
+			limit--
+			queue = append(queue, value)
+		}
+	}
+
+	chars := []rune(text)
+	var collect func(node *Node) []DocumentSymbol
+	collect = func(node *Node) []DocumentSymbol {
+		var symbols []DocumentSymbol
+		for i, child := range node.Children {
+			if !selected[child] {
+				continue
+			}
+			value := symbolValue(node, child)
+			symbol := DocumentSymbol{
+				Name:           strconv.Itoa(i),
+				Detail:         symbolDetail(value, chars),
+				Kind:           value.Type,
+				Range:          Range{Offset: child.Offset, Length: child.Length},
+				SelectionRange: Range{Offset: child.Offset, Length: child.Length},
+				Children:       collect(value),
+			}
+			if node.Type == Object {
+				key := child.Children[0]
+				symbol.Name = symbolName(key.Value.(string))
+				symbol.SelectionRange = Range{Offset: key.Offset, Length: key.Length}
+			}
+			symbols = append(symbols, symbol)
+		}
+		return symbols
+	}
+	return collect(root)
+}
+
+// symbolValue returns the value of the child of the array or object, which is the
+// child itself for an array element, or the property's value. It returns nil for
+// a property without a value, or if the node is not an array or object.
+func symbolValue(node, child *Node) *Node {
+	switch node.Type {
+	case Array:
+		return child
+	case Object:
+		if len(child.Children) < 2 {
+			return nil
+		}
+		return child.Children[1]
+	}
+	return nil
+}
+
+// symbolName returns the name of a symbol for the property name, which is quoted if
+// it is blank.
+func symbolName(name string) string {
+	if strings.TrimSpace(name) == "" {
+		data, _ := json.Marshal(name)
+		return string(data)
+	}
+	return name
+}
+
+func symbolDetail(value *Node, chars []rune) string {
+	switch value.Type {
+	case Array:
+		if len(value.Children) == 0 {
+			return "[]"
+		}
+	case Object:
+		if len(value.Children) == 0 {
+			return "{}"
+		}
+	default:
+		if value.Offset >= 0 && value.Offset+value.Length <= len(chars) {
+			return string(chars[value.Offset : value.Offset+value.Length])
+		}
+	}
+	return ""
+}
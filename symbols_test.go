@@ -0,0 +1,84 @@
+// This file was ported from https://github.com/microsoft/vscode-json-languageservice/blob/main/src/test/documentSymbols.test.ts,
+// which is licensed as follows:
+//
+// Copyright (c) Microsoft Corporation. All rights reserved. Licensed under the MIT License.
+
+package jsonx
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGetDocumentSymbols(t *testing.T) {
+	tests := map[string]struct {
+		input   string
+		options DocumentSymbolOptions
+		want    []DocumentSymbol
+	}{
+		"empty document": {
+			input: "",
+		},
+		"literal": {
+			input: "1",
+		},
+		"object": {
+			input: `{"a": 1, "b": "x", "c": null, "d": false, "e": [], "f": {}}`,
+			want: []DocumentSymbol{
+				{Name: "a", Detail: "1", Kind: Number, Range: Range{1, 6}, SelectionRange: Range{1, 3}},
+				{Name: "b", Detail: `"x"`, Kind: String, Range: Range{9, 8}, SelectionRange: Range{9, 3}},
+				{Name: "c", Detail: "null", Kind: Null, Range: Range{19, 9}, SelectionRange: Range{19, 3}},
+				{Name: "d", Detail: "false", Kind: Boolean, Range: Range{30, 10}, SelectionRange: Range{30, 3}},
+				{Name: "e", Detail: "[]", Kind: Array, Range: Range{42, 7}, SelectionRange: Range{42, 3}},
+				{Name: "f", Detail: "{}", Kind: Object, Range: Range{51, 7}, SelectionRange: Range{51, 3}},
+			},
+		},
+		"nested": {
+			input: `{"a": {"b": [true, {"c": 1}]}}`,
+			want: []DocumentSymbol{
+				{Name: "a", Kind: Object, Range: Range{1, 28}, SelectionRange: Range{1, 3}, Children: []DocumentSymbol{
+					{Name: "b", Kind: Array, Range: Range{7, 21}, SelectionRange: Range{7, 3}, Children: []DocumentSymbol{
+						{Name: "0", Detail: "true", Kind: Boolean, Range: Range{13, 4}, SelectionRange: Range{13, 4}},
+						{Name: "1", Kind: Object, Range: Range{19, 8}, SelectionRange: Range{19, 8}, Children: []DocumentSymbol{
+							{Name: "c", Detail: "1", Kind: Number, Range: Range{20, 6}, SelectionRange: Range{20, 3}},
+						}},
+					}},
+				}},
+			},
+		},
+		"array": {
+			input: `[1, "😀"]`,
+			want: []DocumentSymbol{
+				{Name: "0", Detail: "1", Kind: Number, Range: Range{1, 1}, SelectionRange: Range{1, 1}},
+				{Name: "1", Detail: `"😀"`, Kind: String, Range: Range{4, 3}, SelectionRange: Range{4, 3}},
+			},
+		},
+		"blank and missing values": {
+			input: `{"": 1, " ": 2, "a":, "b"}`,
+			want: []DocumentSymbol{
+				{Name: `""`, Detail: "1", Kind: Number, Range: Range{1, 5}, SelectionRange: Range{1, 2}},
+				{Name: `" "`, Detail: "2", Kind: Number, Range: Range{8, 6}, SelectionRange: Range{8, 3}},
+			},
+		},
+		"result limit": {
+			input:   `{"a": {"b": 1}, "c": 2, "d": [3]}`,
+			options: DocumentSymbolOptions{ResultLimit: 4},
+			want: []DocumentSymbol{
+				{Name: "a", Kind: Object, Range: Range{1, 13}, SelectionRange: Range{1, 3}, Children: []DocumentSymbol{
+					{Name: "b", Detail: "1", Kind: Number, Range: Range{7, 6}, SelectionRange: Range{7, 3}},
+				}},
+				{Name: "c", Detail: "2", Kind: Number, Range: Range{16, 6}, SelectionRange: Range{16, 3}},
+				{Name: "d", Kind: Array, Range: Range{24, 8}, SelectionRange: Range{24, 3}},
+			},
+		},
+	}
+	for label, test := range tests {
+		t.Run(label, func(t *testing.T) {
+			root, _ := ParseTree(test.input, ParseOptions{Comments: true, TrailingCommas: true})
+			got := GetDocumentSymbols(root, test.input, test.options)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got  %+v\nwant %+v", got, test.want)
+			}
+		})
+	}
+}
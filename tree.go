@@ -114,6 +114,15 @@ func MakePath(components ...interface{}) Path {
 	return Path(segments)
 }
 
+// String returns the JSON encoding of the path (such as `["a",0]`).
+func (p Path) String() string {
+	data, err := json.Marshal(p)
+	if err != nil {
+		panic(err) // should never happen
+	}
+	return string(data)
+}
+
 // ParseTree parses the given text and returns a tree representation the JSON content. On
 // invalid input, the parser tries to be as fault tolerant as possible, but still return a result.
 //
@@ -142,6 +151,7 @@ func ParseTree(text string, options ParseOptions) (root *Node, errors []ParseErr
 			currentParent.Children = append(currentParent.Children, &Node{Type: String, Value: name, Offset: offset, Length: length, Parent: currentParent})
 		},
 		OnObjectEnd: func(offset, length int) {
+			ensurePropertyComplete(offset) // in case of a missing value for a property
 			currentParent.Length = offset + length - currentParent.Offset
 			currentParent = currentParent.Parent
 			ensurePropertyComplete(offset + length)
@@ -231,6 +241,9 @@ func FindNodeAtLocation(root *Node, path Path) *Node {
 			}
 			found := false
 			for _, propertyNode := range node.Children {
+				if len(propertyNode.Children) < 2 {
+					continue // property without a value
+				}
 				if propertyNode.Children[0].Value.(string) == segment.Property {
 					node = propertyNode.Children[1]
 					found = true
@@ -251,6 +264,63 @@ func FindNodeAtLocation(root *Node, path Path) *Node {
 	return node
 }
 
+// FindNodeAtOffset returns the innermost node under the JSON document parse tree root
+// that contains the character offset. If includeRightBound, a node also contains the
+// offset just past its end. If no such node exists, it returns nil.
+//
+// Source: https://github.com/microsoft/node-jsonc-parser/blob/main/src/impl/parser.ts
+func FindNodeAtOffset(root *Node, offset int, includeRightBound bool) *Node {
+	if root == nil {
+		return nil
+	}
+	contains := func(node *Node) bool {
+		return offset >= node.Offset && offset < node.Offset+node.Length || includeRightBound && offset == node.Offset+node.Length
+	}
+	if !contains(root) {
+		return nil
+	}
+	node := root
+	for {
+		var next *Node
+		for _, child := range node.Children {
+			if child.Offset > offset {
+				break
+			}
+			if contains(child) {
+				next = child
+				break
+			}
+		}
+		if next == nil {
+			return node
+		}
+		node = next
+	}
+}
+
+// NodePath returns the key path of the JSON parse tree node from the root of its
+// tree. For a property's key, it returns the path of the property's value.
+//
+// Source: https://github.com/microsoft/node-jsonc-parser/blob/main/src/impl/parser.ts
+func NodePath(node *Node) Path {
+	if node == nil || node.Parent == nil {
+		return Path{}
+	}
+	path := NodePath(node.Parent)
+	switch node.Parent.Type {
+	case Property:
+		path = append(path, Segment{IsProperty: true, Property: node.Parent.Children[0].Value.(string)})
+	case Array:
+		for i, child := range node.Parent.Children {
+			if child == node {
+				path = append(path, Segment{Index: i})
+				break
+			}
+		}
+	}
+	return path
+}
+
 // NodeValue returns the JSON parse tree node's value.
 //
 // Source: https://github.com/Microsoft/vscode/blob/c0bc1ace7ca3ce2d6b1aeb2bde9d1bb0f4b4bae6/src/vs/base/common/json.ts#L782
@@ -266,6 +336,9 @@ func NodeValue(node Node) interface{} {
 	case Object:
 		object := make(map[string]interface{}, len(node.Children))
 		for _, prop := range node.Children {
+			if len(prop.Children) < 2 {
+				continue // property without a value
+			}
 			object[prop.Children[0].Value.(string)] = NodeValue(*prop.Children[1])
 		}
 		return object
@@ -165,3 +165,116 @@ func TestPath_JSON(t *testing.T) {
 	}
 
 }
+
+func FuzzParseTree(f *testing.F) {
+	for _, input := range fuzzSeedCorpus(f) {
+		f.Add(input)
+	}
+	f.Fuzz(func(t *testing.T, input string) {
+		n := len([]rune(input))
+		root, errors := ParseTree(input, ParseOptions{Comments: true, TrailingCommas: true})
+		if root == nil {
+			return
+		}
+		var check func(node *Node)
+		check = func(node *Node) {
+			if node.Offset < 0 || node.Length < 0 || node.Offset+node.Length > n {
+				t.Fatalf("%q: %s node at offset %d, length %d out of bounds", input, node.Type, node.Offset, node.Length)
+			}
+			if node.Type == Property && (len(node.Children) == 0 || len(node.Children) > 2 || node.Children[0].Type != String) {
+				t.Fatalf("%q: malformed property node at offset %d", input, node.Offset)
+			}
+			for _, child := range node.Children {
+				if child.Parent != node {
+					t.Fatalf("%q: %s node at offset %d has wrong parent", input, child.Type, child.Offset)
+				}
+				if child.Offset < node.Offset || child.Offset+child.Length > node.Offset+node.Length {
+					t.Fatalf("%q: %s node at offset %d, length %d is outside of its parent", input, child.Type, child.Offset, child.Length)
+				}
+				check(child)
+			}
+		}
+		check(root)
+
+		value, err := json.Marshal(NodeValue(*root))
+		if err != nil {
+			t.Fatalf("%q: NodeValue: %s", input, err)
+		}
+		if len(errors) == 0 {
+			output, _ := Parse(input, ParseOptions{Comments: true, TrailingCommas: true})
+			var got, want interface{}
+			if err := json.Unmarshal(value, &got); err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal(output, &want); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("%q: NodeValue returned %s, want %s", input, value, output)
+			}
+		}
+	})
+}
+
+func TestFindNodeAtOffset(t *testing.T) {
+	input := `{"a": [1, {"b": true}], "c": null}`
+	root, _ := ParseTree(input, ParseOptions{})
+	tests := []struct {
+		offset            int
+		includeRightBound bool
+		want              string // the text of the node
+	}{
+		{offset: 0, want: input},
+		{offset: 1, want: `"a"`},
+		{offset: 4, want: `"a": [1, {"b": true}]`},
+		{offset: 6, want: `[1, {"b": true}]`},
+		{offset: 7, want: `1`},
+		{offset: 8, want: `[1, {"b": true}]`},
+		{offset: 8, includeRightBound: true, want: `1`},
+		{offset: 16, want: `true`},
+		{offset: 29, want: `null`},
+		{offset: 33, want: input},
+		{offset: 34, want: ""},
+		{offset: 34, includeRightBound: true, want: input},
+		{offset: -1, want: ""},
+	}
+	for _, test := range tests {
+		var got string
+		if node := FindNodeAtOffset(root, test.offset, test.includeRightBound); node != nil {
+			got = input[node.Offset : node.Offset+node.Length]
+		}
+		if got != test.want {
+			t.Errorf("offset %d (includeRightBound %v): got %q, want %q", test.offset, test.includeRightBound, got, test.want)
+		}
+	}
+	if node := FindNodeAtOffset(nil, 0, true); node != nil {
+		t.Errorf("got %+v for a nil root, want nil", node)
+	}
+}
+
+func TestNodePath(t *testing.T) {
+	input := `{"a": [1, {"b": true}], "c": null}`
+	root, _ := ParseTree(input, ParseOptions{})
+	tests := []struct {
+		offset int
+		want   Path
+	}{
+		{offset: 0, want: Path{}},
+		{offset: 1, want: MakePath("a")},
+		{offset: 4, want: Path{}},
+		{offset: 6, want: MakePath("a")},
+		{offset: 7, want: MakePath("a", 0)},
+		{offset: 11, want: MakePath("a", 1, "b")},
+		{offset: 16, want: MakePath("a", 1, "b")},
+		{offset: 29, want: MakePath("c")},
+	}
+	for _, test := range tests {
+		node := FindNodeAtOffset(root, test.offset, false)
+		if got := NodePath(node); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("offset %d: got %s, want %s", test.offset, got, test.want)
+		}
+	}
+	if got := NodePath(nil); len(got) != 0 {
+		t.Errorf("got %s for a nil node, want []", got)
+	}
+}
@@ -13,11 +13,13 @@ import "encoding/json"
 // Source: https://github.com/Microsoft/vscode/blob/c0bc1ace7ca3ce2d6b1aeb2bde9d1bb0f4b4bae6/src/vs/base/common/json.ts#L1008
 type Visitor struct {
 	// Invoked when an open brace is encountered and an object is started. The
-	// offset and length represent the location of the open brace.
+	// offset and length represent the location of the open brace. To skip the
+	// object's properties, call the Walker's SkipChildren method.
 	OnObjectBegin func(offset, length int)
 
 	// Invoked when a property is encountered. The offset and length represent
-	// the location of the property name.
+	// the location of the property name. To skip the property's value, call the
+	// Walker's SkipChildren method.
 	OnObjectProperty func(property string, offset, length int)
 
 	// Invoked when a closing brace is encountered and an object is completed.
@@ -25,7 +27,8 @@ type Visitor struct {
 	OnObjectEnd func(offset, length int)
 
 	// Invoked when an open bracket is encountered. The offset and length represent
-	// the location of the open bracket.
+	// the location of the open bracket. To skip the array's elements, call the
+	// Walker's SkipChildren method.
 	OnArrayBegin func(offset, length int)
 
 	// Invoked when a closing bracket is encountered. The offset and length represent
@@ -49,18 +52,30 @@ type Visitor struct {
 //
 // Source: https://github.com/Microsoft/vscode/blob/c0bc1ace7ca3ce2d6b1aeb2bde9d1bb0f4b4bae6/src/vs/base/common/json.ts#L799
 func Walk(text string, options ParseOptions, visitor Visitor) bool {
-	scanner := NewScanner(text, ScanOptions{Trivia: true})
-	walker := walker{scanner: scanner, options: options, visitor: visitor}
-	return walker.walk()
+	return NewWalker(text, options, visitor).Walk()
 }
 
-type walker struct {
+// A Walker walks a JSON document like Walk, and lets the visitor's funcs control
+// the walk by calling its methods.
+type Walker struct {
 	scanner *Scanner
 	options ParseOptions
 	visitor Visitor
+
+	path    Path // the path of the current value
+	skip    bool // whether SkipChildren was called
+	stopped bool // whether Stop was called
+}
+
+// NewWalker returns a Walker for the JSON document text that calls the visitor's
+// funcs. To call the Walker's methods from the funcs, the funcs must refer to the
+// returned Walker (for example, through a variable declared before the Visitor).
+func NewWalker(text string, options ParseOptions, visitor Visitor) *Walker {
+	return &Walker{scanner: NewScanner(text, ScanOptions{Trivia: true}), options: options, visitor: visitor, path: Path{}}
 }
 
-func (w *walker) walk() bool {
+// Walk parses the document and calls the visitor's funcs, like the Walk function.
+func (w *Walker) Walk() bool {
 	w.scanNext()
 	if w.scanner.Token() == EOF {
 		return true
@@ -75,56 +90,100 @@ func (w *walker) walk() bool {
 	return true
 }
 
-func (w *walker) onObjectBegin() {
-	if w.visitor.OnObjectBegin != nil {
+// SkipChildren skips the properties of the object, the elements of the array or
+// the value of the property for which the OnObjectBegin, OnArrayBegin or
+// OnObjectProperty func is being called. The skipped values are only scanned
+// for the end of the object or array, so no funcs are called for them (except
+// OnObjectEnd and OnArrayEnd for the skipped object or array), including for
+// their errors. Calls from other funcs are ignored.
+func (w *Walker) SkipChildren() {
+	w.skip = true
+}
+
+// Stop stops the walk after the func that calls it returns. No more funcs are
+// called, and Walk returns.
+func (w *Walker) Stop() {
+	w.stopped = true
+}
+
+// Path returns the key path of the current value, for the visitor's funcs: in
+// OnObjectBegin, OnObjectEnd, OnArrayBegin and OnArrayEnd, the path of the object
+// or array; in OnObjectProperty, the path of the property's value; in
+// OnLiteralValue, the path of the value; and in OnSeparator and OnError, the path
+// of the property or element before which the separator or error is (or of the
+// enclosing object).
+//
+// The returned path is only valid until the func returns, because the Walker
+// reuses its memory. To keep it, copy it.
+func (w *Walker) Path() Path {
+	return w.path
+}
+
+// skipRequested reports whether SkipChildren was called since the last call to
+// skipRequested.
+func (w *Walker) skipRequested() bool {
+	skip := w.skip
+	w.skip = false
+	return skip
+}
+
+func (w *Walker) onObjectBegin() {
+	w.skip = false
+	if w.visitor.OnObjectBegin != nil && !w.stopped {
 		w.visitor.OnObjectBegin(w.scanner.TokenOffset(), w.scanner.TokenLength())
 	}
 }
 
-func (w *walker) onObjectProperty(property string) {
-	if w.visitor.OnObjectProperty != nil {
+func (w *Walker) onObjectProperty(property string) {
+	w.skip = false
+	if w.visitor.OnObjectProperty != nil && !w.stopped {
 		w.visitor.OnObjectProperty(property, w.scanner.TokenOffset(), w.scanner.TokenLength())
 	}
 }
 
-func (w *walker) onObjectEnd() {
-	if w.visitor.OnObjectEnd != nil {
+func (w *Walker) onObjectEnd() {
+	if w.visitor.OnObjectEnd != nil && !w.stopped {
 		w.visitor.OnObjectEnd(w.scanner.TokenOffset(), w.scanner.TokenLength())
 	}
 }
 
-func (w *walker) onArrayBegin() {
-	if w.visitor.OnArrayBegin != nil {
+func (w *Walker) onArrayBegin() {
+	w.skip = false
+	if w.visitor.OnArrayBegin != nil && !w.stopped {
 		w.visitor.OnArrayBegin(w.scanner.TokenOffset(), w.scanner.TokenLength())
 	}
 }
 
-func (w *walker) onArrayEnd() {
-	if w.visitor.OnArrayEnd != nil {
+func (w *Walker) onArrayEnd() {
+	if w.visitor.OnArrayEnd != nil && !w.stopped {
 		w.visitor.OnArrayEnd(w.scanner.TokenOffset(), w.scanner.TokenLength())
 	}
 }
 
-func (w *walker) onLiteralValue(value interface{}) {
-	if w.visitor.OnLiteralValue != nil {
+func (w *Walker) onLiteralValue(value interface{}) {
+	if w.visitor.OnLiteralValue != nil && !w.stopped {
 		w.visitor.OnLiteralValue(value, w.scanner.TokenOffset(), w.scanner.TokenLength())
 	}
 }
 
-func (w *walker) onSeparator(character rune) {
-	if w.visitor.OnSeparator != nil {
+func (w *Walker) onSeparator(character rune) {
+	if w.visitor.OnSeparator != nil && !w.stopped {
 		w.visitor.OnSeparator(character, w.scanner.TokenOffset(), w.scanner.TokenLength())
 	}
 }
 
-func (w *walker) onError(errorCode ParseErrorCode) {
-	if w.visitor.OnError != nil {
+func (w *Walker) onError(errorCode ParseErrorCode) {
+	if w.visitor.OnError != nil && !w.stopped {
 		w.visitor.OnError(errorCode, w.scanner.TokenOffset(), w.scanner.TokenLength())
 	}
 }
 
-func (w *walker) scanNext() SyntaxKind {
+func (w *Walker) scanNext() SyntaxKind {
 	for {
+		if w.stopped {
+			w.scanner.SetPosition(w.scanner.len) // scan EOF to end the walk
+			return w.scanner.Scan()
+		}
 		token := w.scanner.Scan()
 		switch w.scanner.Err() {
 		case None:
@@ -162,7 +221,7 @@ func (w *walker) scanNext() SyntaxKind {
 	}
 }
 
-func (w *walker) handleError(errorCode ParseErrorCode, skipUntilAfter, skipUntil []SyntaxKind) {
+func (w *Walker) handleError(errorCode ParseErrorCode, skipUntilAfter, skipUntil []SyntaxKind) {
 	indexOf := func(slice []SyntaxKind, candidateElement SyntaxKind) int {
 		for i, e := range slice {
 			if e == candidateElement {
@@ -187,21 +246,27 @@ func (w *walker) handleError(errorCode ParseErrorCode, skipUntilAfter, skipUntil
 	}
 }
 
-func (w *walker) parseString(isValue bool) bool {
+func (w *Walker) parseString(isValue bool) bool {
 	value := string(w.scanner.Value())
 	if isValue {
 		w.onLiteralValue(value)
 	} else {
+		w.path = append(w.path, Segment{IsProperty: true, Property: value})
 		w.onObjectProperty(value)
 	}
 	w.scanNext()
 	return true
 }
 
-func (w *walker) parseLiteral() bool {
+func (w *Walker) parseLiteral() bool {
 	switch w.scanner.Token() {
 	case NumericLiteral:
 		value := json.Number(w.scanner.Value())
+		if w.scanner.Err() != None && !json.Valid([]byte(value)) {
+			// Incomplete numbers (such as `1.`) are already reported by the
+			// scanner, but they must not end up in the output, so drop them.
+			break
+		}
 		if _, err := value.Float64(); err != nil {
 			w.handleError(InvalidNumberFormat, nil, nil)
 		}
@@ -219,27 +284,36 @@ func (w *walker) parseLiteral() bool {
 	return true
 }
 
-func (w *walker) parseProperty() bool {
+func (w *Walker) parseProperty() bool {
 	if w.scanner.Token() != StringLiteral {
 		w.handleError(PropertyNameExpected, nil, []SyntaxKind{CloseBraceToken, CommaToken})
 		return false
 	}
 	w.parseString(false)
+	skip := w.skipRequested()
 	if w.scanner.Token() == ColonToken {
 		w.onSeparator(':')
 		w.scanNext() // consume colon
 
-		if !w.parseValue() {
+		if skip {
+			if !w.skipValue() {
+				w.handleError(ValueExpected, nil, []SyntaxKind{CloseBraceToken, CommaToken})
+			}
+		} else if !w.parseValue() {
 			w.handleError(ValueExpected, nil, []SyntaxKind{CloseBraceToken, CommaToken})
 		}
 	} else {
 		w.handleError(ColonExpected, nil, []SyntaxKind{CloseBraceToken, CommaToken})
 	}
+	w.path = w.path[:len(w.path)-1]
 	return true
 }
 
-func (w *walker) parseObject() bool {
+func (w *Walker) parseObject() bool {
 	w.onObjectBegin()
+	if w.skipRequested() {
+		w.scanner.skipContainer()
+	}
 	w.scanNext() // consume open brace
 
 	needsComma := false
@@ -270,15 +344,20 @@ func (w *walker) parseObject() bool {
 	return true
 }
 
-func (w *walker) parseArray() bool {
+func (w *Walker) parseArray() bool {
 	w.onArrayBegin()
+	if w.skipRequested() {
+		w.scanner.skipContainer()
+	}
 	w.scanNext() // consume open bracket
 
+	w.path = append(w.path, Segment{}) // the index of the next element
 	needsComma := false
 	for w.scanner.Token() != CloseBracketToken && w.scanner.Token() != EOF {
 		if w.scanner.Token() == CommaToken {
 			if !needsComma {
 				w.handleError(ValueExpected, nil, nil)
+				w.path[len(w.path)-1].Index++ // the missing element
 			}
 			w.onSeparator(',')
 			w.scanNext() // consume comma
@@ -291,8 +370,10 @@ func (w *walker) parseArray() bool {
 		if !w.parseValue() {
 			w.handleError(ValueExpected, nil, []SyntaxKind{CloseBracketToken, CommaToken})
 		}
+		w.path[len(w.path)-1].Index++
 		needsComma = true
 	}
+	w.path = w.path[:len(w.path)-1]
 	w.onArrayEnd()
 	if w.scanner.Token() != CloseBracketToken {
 		w.handleError(CloseBracketExpected, []SyntaxKind{CloseBracketToken}, nil)
@@ -302,7 +383,7 @@ func (w *walker) parseArray() bool {
 	return true
 }
 
-func (w *walker) parseValue() bool {
+func (w *Walker) parseValue() bool {
 	switch w.scanner.Token() {
 	case OpenBracketToken:
 		return w.parseArray()
@@ -314,3 +395,20 @@ func (w *walker) parseValue() bool {
 		return w.parseLiteral()
 	}
 }
+
+// skipValue skips the value at the current token without calling the visitor's
+// funcs, and returns false if there is no value.
+func (w *Walker) skipValue() bool {
+	switch w.scanner.Token() {
+	case OpenBraceToken, OpenBracketToken:
+		w.scanner.skipContainer()
+		if token := w.scanNext(); token == CloseBraceToken || token == CloseBracketToken {
+			w.scanNext() // consume close brace or bracket
+		}
+	case StringLiteral, NumericLiteral, NullKeyword, TrueKeyword, FalseKeyword:
+		w.scanNext()
+	default:
+		return false
+	}
+	return true
+}
@@ -1,6 +1,10 @@
 package jsonx
 
-import "testing"
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
 
 func TestWalkerParseErrors(t *testing.T) {
 	const noParseErrorCode ParseErrorCode = -1
@@ -128,3 +132,211 @@ func TestWalkerParseErrors(t *testing.T) {
 		}
 	})
 }
+
+func FuzzWalk(f *testing.F) {
+	for _, input := range fuzzSeedCorpus(f) {
+		f.Add(input, true, true)
+	}
+	f.Fuzz(func(t *testing.T, input string, comments, trailingCommas bool) {
+		n := len([]rune(input))
+		checkBounds := func(event string, offset, length int) {
+			if offset < 0 || length < 0 || offset+length > n {
+				t.Fatalf("%q: %s at offset %d, length %d out of bounds", input, event, offset, length)
+			}
+		}
+		var stack []rune
+		begin := func(event string, ch rune) func(offset, length int) {
+			return func(offset, length int) {
+				checkBounds(event, offset, length)
+				stack = append(stack, ch)
+			}
+		}
+		end := func(event string, ch rune) func(offset, length int) {
+			return func(offset, length int) {
+				checkBounds(event, offset, length)
+				if len(stack) == 0 || stack[len(stack)-1] != ch {
+					t.Fatalf("%q: unbalanced %s at offset %d", input, event, offset)
+				}
+				stack = stack[:len(stack)-1]
+			}
+		}
+		Walk(input, ParseOptions{Comments: comments, TrailingCommas: trailingCommas}, Visitor{
+			OnObjectBegin: begin("OnObjectBegin", '{'),
+			OnObjectEnd:   end("OnObjectEnd", '{'),
+			OnArrayBegin:  begin("OnArrayBegin", '['),
+			OnArrayEnd:    end("OnArrayEnd", '['),
+			OnObjectProperty: func(property string, offset, length int) {
+				checkBounds("OnObjectProperty", offset, length)
+			},
+			OnLiteralValue: func(value interface{}, offset, length int) {
+				checkBounds("OnLiteralValue", offset, length)
+			},
+			OnSeparator: func(character rune, offset, length int) {
+				checkBounds("OnSeparator", offset, length)
+			},
+			OnError: func(errorCode ParseErrorCode, offset, length int) {
+				checkBounds("OnError", offset, length)
+			},
+		})
+		if len(stack) != 0 {
+			t.Fatalf("%q: %d unclosed objects or arrays after Walk", input, len(stack))
+		}
+	})
+}
+
+func TestWalker(t *testing.T) {
+	const input = `{"a": {"x": [1, "]", /* ] */ {}]}, "b": [2, [3]], "c": 4, "d": [1 2]}`
+	tests := map[string]struct {
+		control func(w *Walker, event string) // called after each event
+		want    string
+	}{
+		"all": {
+			control: func(w *Walker, event string) {},
+			want:    `{ a { x [ 1 "]" { } ] } b [ 2 [ 3 ] ] c 4 d [ 1 CommaExpected 2 ] }`,
+		},
+		"skip object": {
+			control: func(w *Walker, event string) {
+				if event == "{" {
+					w.SkipChildren()
+				}
+			},
+			want: `{ }`,
+		},
+		"skip property values": {
+			control: func(w *Walker, event string) {
+				if event == "a" || event == "c" || event == "d" {
+					w.SkipChildren()
+				}
+			},
+			want: `{ a b [ 2 [ 3 ] ] c d }`,
+		},
+		"skip arrays": {
+			control: func(w *Walker, event string) {
+				if event == "[" {
+					w.SkipChildren()
+				}
+			},
+			want: `{ a { x [ ] } b [ ] c 4 d [ ] }`,
+		},
+		"ignored skip": {
+			control: func(w *Walker, event string) {
+				if event == "1" || event == "}" {
+					w.SkipChildren()
+				}
+			},
+			want: `{ a { x [ 1 "]" { } ] } b [ 2 [ 3 ] ] c 4 d [ 1 CommaExpected 2 ] }`,
+		},
+		"stop": {
+			control: func(w *Walker, event string) {
+				if event == "3" {
+					w.Stop()
+				}
+			},
+			want: `{ a { x [ 1 "]" { } ] } b [ 2 [ 3`,
+		},
+		"stop on error": {
+			control: func(w *Walker, event string) {
+				if event == "CommaExpected" {
+					w.Stop()
+				}
+			},
+			want: `{ a { x [ 1 "]" { } ] } b [ 2 [ 3 ] ] c 4 d [ 1 CommaExpected`,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var events []string
+			var w *Walker
+			event := func(event string) {
+				events = append(events, event)
+				test.control(w, event)
+			}
+			w = NewWalker(input, ParseOptions{Comments: true}, Visitor{
+				OnObjectBegin:    func(offset, length int) { event("{") },
+				OnObjectEnd:      func(offset, length int) { event("}") },
+				OnArrayBegin:     func(offset, length int) { event("[") },
+				OnArrayEnd:       func(offset, length int) { event("]") },
+				OnObjectProperty: func(property string, offset, length int) { event(property) },
+				OnLiteralValue: func(value interface{}, offset, length int) {
+					if s, ok := value.(string); ok {
+						event(`"` + s + `"`)
+					} else {
+						event(fmt.Sprint(value))
+					}
+				},
+				OnError: func(errorCode ParseErrorCode, offset, length int) { event(errorCode.String()) },
+			})
+			if !w.Walk() {
+				t.Error("got false from Walk")
+			}
+			if got := strings.Join(events, " "); got != test.want {
+				t.Errorf("got  %s\nwant %s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestWalker_Path(t *testing.T) {
+	const input = `{"a": [1, {"b": null}, [], ], "c": {}, "d" 2, "e": [,3 4]}`
+	var events []string
+	var w *Walker
+	event := func(event string) {
+		events = append(events, event+" "+w.Path().String())
+	}
+	w = NewWalker(input, ParseOptions{TrailingCommas: true}, Visitor{
+		OnObjectBegin:    func(offset, length int) { event("{") },
+		OnObjectEnd:      func(offset, length int) { event("}") },
+		OnArrayBegin:     func(offset, length int) { event("[") },
+		OnArrayEnd:       func(offset, length int) { event("]") },
+		OnObjectProperty: func(property string, offset, length int) { event(property) },
+		OnLiteralValue:   func(value interface{}, offset, length int) { event(fmt.Sprint(value)) },
+		OnSeparator:      func(character rune, offset, length int) { event(string(character)) },
+		OnError:          func(errorCode ParseErrorCode, offset, length int) { event(errorCode.String()) },
+	})
+	w.Walk()
+	want := []string{
+		`{ []`,
+		`a ["a"]`, `: ["a"]`,
+		`[ ["a"]`, `1 ["a",0]`, `, ["a",1]`,
+		`{ ["a",1]`, `b ["a",1,"b"]`, `: ["a",1,"b"]`, `<nil> ["a",1,"b"]`, `} ["a",1]`, `, ["a",2]`,
+		`[ ["a",2]`, `] ["a",2]`, `, ["a",3]`,
+		`] ["a"]`, `, []`,
+		`c ["c"]`, `: ["c"]`, `{ ["c"]`, `} ["c"]`, `, []`,
+		`d ["d"]`, `ColonExpected ["d"]`, `, []`,
+		`e ["e"]`, `: ["e"]`, `[ ["e"]`, `ValueExpected ["e",0]`, `, ["e",1]`, `3 ["e",1]`, `CommaExpected ["e",2]`, `4 ["e",2]`, `] ["e"]`,
+		`} []`,
+	}
+	if got := strings.Join(events, "\n"); got != strings.Join(want, "\n") {
+		t.Errorf("got\n%s\nwant\n%s", got, strings.Join(want, "\n"))
+	}
+
+	// Getting the path must not allocate.
+	walk := func(path bool) float64 {
+		return testing.AllocsPerRun(10, func() {
+			var w *Walker
+			depth := 0
+			event := func() {
+				if path {
+					depth += len(w.Path())
+				}
+			}
+			w = NewWalker(input, ParseOptions{TrailingCommas: true}, Visitor{
+				OnObjectBegin:    func(offset, length int) { event() },
+				OnObjectEnd:      func(offset, length int) { event() },
+				OnArrayBegin:     func(offset, length int) { event() },
+				OnArrayEnd:       func(offset, length int) { event() },
+				OnObjectProperty: func(property string, offset, length int) { event() },
+				OnLiteralValue:   func(value interface{}, offset, length int) { event() },
+				OnSeparator:      func(character rune, offset, length int) { event() },
+				OnError:          func(errorCode ParseErrorCode, offset, length int) { event() },
+			})
+			w.Walk()
+			if path && depth == 0 {
+				t.Error("got no path")
+			}
+		})
+	}
+	if with, without := walk(true), walk(false); with != without {
+		t.Errorf("got %v allocations with the path, %v without", with, without)
+	}
+}